@@ -30,7 +30,7 @@ var dryrunCmd = &cobra.Command{
 	Long:  `Process a NodeFeatureRule file against a local NodeFeature file to dry run the rule against a node before applying it to a cluster`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Evaluating NodeFeatureRule %q against NodeFeature %q\n", nodefeaturerule, nodefeature)
-		err := kubectlnfd.DryRun(nodefeaturerule, nodefeature)
+		err := kubectlnfd.DryRun(nodefeaturerule, nodefeature, explain)
 		if len(err) > 0 {
 			fmt.Printf("NodeFeatureRule %q is not valid for NodeFeature %q\n", nodefeaturerule, nodefeature)
 			for _, e := range err {
@@ -48,6 +48,7 @@ func init() {
 
 	dryrunCmd.Flags().StringVarP(&nodefeaturerule, "nodefeaturerule-file", "f", "", "Path to the NodeFeatureRule file to validate")
 	dryrunCmd.Flags().StringVarP(&nodefeature, "nodefeature-file", "n", "", "Path to the NodeFeature file to validate against")
+	dryrunCmd.Flags().BoolVar(&explain, "explain", false, "Print a trace of the evaluation of each rule")
 	err := dryrunCmd.MarkFlagRequired("nodefeaturerule-file")
 	if err != nil {
 		panic(err)
@@ -30,7 +30,7 @@ var testCmd = &cobra.Command{
 	Long:  `Test a NodeFeatureRule file against a Node to ensure it is valid before applying it to a cluster`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Printf("Evaluating NodeFeatureRule against Node %s\n", node)
-		err := kubectlnfd.Test(nodefeaturerule, node, kubeconfig)
+		err := kubectlnfd.Test(nodefeaturerule, node, kubeconfig, explain)
 		if len(err) > 0 {
 			fmt.Printf("NodeFeatureRule is not valid for Node %s\n", node)
 			for _, e := range err {
@@ -49,6 +49,7 @@ func init() {
 	testCmd.Flags().StringVarP(&nodefeaturerule, "nodefeaturerule-file", "f", "", "Path to the NodeFeatureRule file to validate")
 	testCmd.Flags().StringVarP(&node, "nodename", "n", "", "Node to validate against")
 	testCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "k", "", "kubeconfig file to use")
+	testCmd.Flags().BoolVar(&explain, "explain", false, "Print a trace of the evaluation of each rule")
 	err := testCmd.MarkFlagRequired("nodefeaturerule-file")
 	if err != nil {
 		panic(err)
@@ -155,6 +155,8 @@ func initFlags(flagset *flag.FlagSet) (*master.Args, *master.ConfigOverrideArgs)
 			"in the same format as in the config file (i.e. json or yaml). These options")
 	flagset.BoolVar(&args.EnableLeaderElection, "enable-leader-election", false,
 		"Enables a leader election. Enable this when running more than one replica on nfd master.")
+	flagset.BoolVar(&args.EnableExplain, "enable-explain", false,
+		"Serve the unauthenticated /debug/explain endpoint on the metrics port for debugging NodeFeatureRule evaluation.")
 
 	args.Klog = klogutils.InitKlogFlags(flagset)
 
@@ -20,12 +20,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"k8s.io/klog/v2"
 	klogutils "sigs.k8s.io/node-feature-discovery/pkg/utils/klog"
 
 	worker "sigs.k8s.io/node-feature-discovery/pkg/nfd-worker"
 	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 	"sigs.k8s.io/node-feature-discovery/pkg/version"
 )
 
@@ -79,6 +82,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Force re-discovery of all feature sources on SIGUSR1
+	refreshSignal := make(chan os.Signal, 1)
+	signal.Notify(refreshSignal, syscall.SIGUSR1)
+	go func() {
+		for range refreshSignal {
+			klog.InfoS("received SIGUSR1, requesting re-discovery of all feature sources")
+			_ = instance.Refresh()
+		}
+	}()
+
 	if err = instance.Run(); err != nil {
 		klog.ErrorS(err, "error while running")
 		os.Exit(1)
@@ -127,6 +140,9 @@ func initFlags(flagset *flag.FlagSet) (*worker.Args, *worker.ConfigOverrideArgs)
 	flagset.BoolVar(&args.EnableNodeFeatureApi, "enable-nodefeature-api", true,
 		"Enable the NodeFeature CRD API for communicating with nfd-master. This will automatically disable the gRPC communication."+
 			" DEPRECATED: will be removed in a future release along with the deprecated gRPC API.")
+	flagset.StringVar(&args.HostRoot, "host-root", "",
+		"Root directory under which the host filesystem is mounted. Overrides the "+
+			hostpath.HostRootEnv+" environment variable and the built-in path prefix.")
 	flagset.StringVar(&args.Kubeconfig, "kubeconfig", "",
 		"Kubeconfig to use")
 	flagset.BoolVar(&args.Oneshot, "oneshot", false,
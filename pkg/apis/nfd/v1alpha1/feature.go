@@ -73,7 +73,8 @@ func (f *Features) InsertAttributeFeatures(domain, feature string, values map[st
 	}
 	key := domain + "." + feature
 	if _, ok := f.Attributes[key]; !ok {
-		f.Attributes[key] = NewAttributeFeatures(values)
+		// Copy the values so that later insertions do not modify the caller's map
+		f.Attributes[key] = NewAttributeFeatures(maps.Clone(values))
 		return
 	}
 
@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+// OutputType is the type of node output a conflict applies to.
+type OutputType string
+
+const (
+	OutputTypeLabel            OutputType = "label"
+	OutputTypeAnnotation       OutputType = "annotation"
+	OutputTypeExtendedResource OutputType = "extendedResource"
+	OutputTypeTaint            OutputType = "taint"
+)
+
+// RuleSource identifies the rule that produced an output value.
+type RuleSource struct {
+	// NodeFeatureRule is the name of the NodeFeatureRule object.
+	NodeFeatureRule string `json:"nodeFeatureRule"`
+	// Rule is the name of the rule within the object.
+	Rule string `json:"rule"`
+	// Priority is the priority of the rule.
+	Priority int32 `json:"priority,omitempty"`
+}
+
+func (s RuleSource) String() string {
+	return s.NodeFeatureRule + "/" + s.Rule
+}
+
+// Conflict describes two rules producing the same output key with different
+// values.
+type Conflict struct {
+	Type OutputType `json:"type"`
+	// Key is the name of the label, annotation or extended resource, or the
+	// <key>:<effect> of the taint.
+	Key         string     `json:"key"`
+	Winner      RuleSource `json:"winner"`
+	WinnerValue string     `json:"winnerValue"`
+	Loser       RuleSource `json:"loser"`
+	LoserValue  string     `json:"loserValue"`
+}
+
+// ResolvedByPriority returns true if the conflict was resolved by rule
+// priorities, i.e. not by evaluation order.
+func (c Conflict) ResolvedByPriority() bool {
+	return c.Winner.Priority != c.Loser.Priority
+}
+
+func (c Conflict) String() string {
+	resolution := "evaluation order"
+	if c.ResolvedByPriority() {
+		resolution = "priority"
+	}
+	return fmt.Sprintf("%s %q: value %q from %s overrides value %q from %s (by %s)",
+		c.Type, c.Key, c.WinnerValue, c.Winner, c.LoserValue, c.Loser, resolution)
+}
+
+// OutputMerger merges the output of multiple rules into the output of the
+// node. Rules must be added in evaluation order. Conflicting values are
+// resolved by rule priority, with the rule added last winning between rules
+// of equal priority.
+type OutputMerger struct {
+	Labels            map[string]string
+	Annotations       map[string]string
+	ExtendedResources map[string]string
+	Taints            []corev1.Taint
+	// Conflicts detected so far, in the order of detection.
+	Conflicts []Conflict
+
+	owners map[OutputType]map[string]RuleSource
+}
+
+// NewOutputMerger creates a new empty OutputMerger.
+func NewOutputMerger() *OutputMerger {
+	return &OutputMerger{
+		Labels:            make(map[string]string),
+		Annotations:       make(map[string]string),
+		ExtendedResources: make(map[string]string),
+		owners:            make(map[OutputType]map[string]RuleSource),
+	}
+}
+
+// Add merges the output of one rule. It returns the conflicts caused by the
+// output, which are also appended to Conflicts.
+func (m *OutputMerger) Add(src RuleSource, out RuleOutput) []Conflict {
+	n := len(m.Conflicts)
+
+	m.addMap(OutputTypeLabel, src, out.Labels, m.Labels)
+	m.addMap(OutputTypeAnnotation, src, out.Annotations, m.Annotations)
+	m.addMap(OutputTypeExtendedResource, src, out.ExtendedResources, m.ExtendedResources)
+
+	for _, taint := range out.Taints {
+		key := taint.Key + ":" + string(taint.Effect)
+		idx := -1
+		for i, t := range m.Taints {
+			if t.Key == taint.Key && t.Effect == taint.Effect {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			m.resolve(OutputTypeTaint, key, "", false, taint.Value, src)
+			m.Taints = append(m.Taints, taint)
+		} else if m.resolve(OutputTypeTaint, key, m.Taints[idx].Value, true, taint.Value, src) {
+			m.Taints[idx] = taint
+		}
+	}
+
+	return m.Conflicts[n:]
+}
+
+func (m *OutputMerger) addMap(typ OutputType, src RuleSource, in, out map[string]string) {
+	// Iterate in sorted order to make the order of conflicts deterministic
+	keys := make([]string, 0, len(in))
+	for k := range in {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		old, exists := out[k]
+		if m.resolve(typ, k, old, exists, in[k], src) {
+			out[k] = in[k]
+		}
+	}
+}
+
+// resolve determines if a new value should replace the existing value of a
+// key, recording a conflict if the values differ.
+func (m *OutputMerger) resolve(typ OutputType, key, old string, exists bool, value string, src RuleSource) bool {
+	owners, ok := m.owners[typ]
+	if !ok {
+		owners = make(map[string]RuleSource)
+		m.owners[typ] = owners
+	}
+
+	owner := owners[key]
+	replace := !exists || src.Priority >= owner.Priority
+	if exists && old != value {
+		c := Conflict{Type: typ, Key: key, Winner: src, WinnerValue: value, Loser: owner, LoserValue: old}
+		if !replace {
+			c.Winner, c.WinnerValue, c.Loser, c.LoserValue = owner, old, src, value
+		}
+		m.Conflicts = append(m.Conflicts, c)
+	}
+	if replace {
+		owners[key] = src
+	}
+	return replace
+}
+
+// WithoutLostLabels returns the rule output without the labels for which the
+// rule lost a conflict against another rule, so that only the labels effective
+// on the node are fed back to subsequent rules. The optional key function maps
+// the label names of the rule output to the names in the merged output.
+func WithoutLostLabels(out RuleOutput, src RuleSource, conflicts []Conflict, key func(string) string) RuleOutput {
+	lost := make(map[string]struct{})
+	for _, c := range conflicts {
+		if c.Type == OutputTypeLabel && c.Loser == src {
+			lost[c.Key] = struct{}{}
+		}
+	}
+	if len(lost) == 0 {
+		return out
+	}
+
+	labels := make(map[string]string, len(out.Labels))
+	for k, v := range out.Labels {
+		mk := k
+		if key != nil {
+			mk = key(k)
+		}
+		if _, ok := lost[mk]; !ok {
+			labels[k] = v
+		}
+	}
+	out.Labels = labels
+	return out
+}
+
+// WithDefaultNs returns the rule output with the default namespaces added to
+// unprefixed label, extended resource and annotation names, as nfd-master does
+// when autoDefaultNs is enabled.
+func WithDefaultNs(out RuleOutput) RuleOutput {
+	out.Labels = addDefaultNs(out.Labels, nfdv1alpha1.FeatureLabelNs)
+	out.ExtendedResources = addDefaultNs(out.ExtendedResources, nfdv1alpha1.ExtendedResourceNs)
+	out.Annotations = addDefaultNs(out.Annotations, nfdv1alpha1.FeatureAnnotationNs)
+	return out
+}
+
+// DefaultNsLabelKey returns the name of a label with the default namespace
+// added if the name is unprefixed.
+func DefaultNsLabelKey(key string) string {
+	if strings.Contains(key, "/") {
+		return key
+	}
+	return path.Join(nfdv1alpha1.FeatureLabelNs, key)
+}
+
+// addDefaultNs adds a namespace to the unprefixed keys of a map. Unprefixed
+// keys are dropped if the map also contains the prefixed key.
+func addDefaultNs(in map[string]string, ns string) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		if strings.Contains(k, "/") {
+			out[k] = v
+		} else {
+			fqn := path.Join(ns, k)
+			if _, ok := in[fqn]; !ok {
+				out[fqn] = v
+			}
+		}
+	}
+	return out
+}
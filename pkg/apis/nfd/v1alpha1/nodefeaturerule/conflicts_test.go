@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestOutputMerger(t *testing.T) {
+	a := RuleSource{NodeFeatureRule: "nfr-a", Rule: "rule-1"}
+	b := RuleSource{NodeFeatureRule: "nfr-b", Rule: "rule-1"}
+	c := RuleSource{NodeFeatureRule: "nfr-c", Rule: "rule-1", Priority: 10}
+
+	m := NewOutputMerger()
+
+	conflicts := m.Add(c, RuleOutput{
+		Labels: map[string]string{"label-1": "c", "label-3": "c"},
+		Taints: []corev1.Taint{{Key: "taint-1", Value: "c", Effect: corev1.TaintEffectNoSchedule}},
+	})
+	assert.Empty(t, conflicts)
+
+	conflicts = m.Add(a, RuleOutput{
+		Labels:            map[string]string{"label-1": "a", "label-2": "a", "label-3": "c"},
+		Annotations:       map[string]string{"annotation-1": "a"},
+		ExtendedResources: map[string]string{"er-1": "1"},
+		Taints: []corev1.Taint{
+			{Key: "taint-1", Value: "a", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "taint-1", Value: "a", Effect: corev1.TaintEffectNoExecute},
+		},
+	})
+	// Higher priority wins
+	assert.Equal(t, []Conflict{
+		{Type: OutputTypeLabel, Key: "label-1", Winner: c, WinnerValue: "c", Loser: a, LoserValue: "a"},
+		{Type: OutputTypeTaint, Key: "taint-1:NoSchedule", Winner: c, WinnerValue: "c", Loser: a, LoserValue: "a"},
+	}, conflicts)
+	assert.True(t, conflicts[0].ResolvedByPriority())
+
+	conflicts = m.Add(b, RuleOutput{
+		Labels:            map[string]string{"label-2": "b"},
+		Annotations:       map[string]string{"annotation-1": "a"},
+		ExtendedResources: map[string]string{"er-1": "2"},
+	})
+	// Last one wins with equal priority
+	assert.Equal(t, []Conflict{
+		{Type: OutputTypeLabel, Key: "label-2", Winner: b, WinnerValue: "b", Loser: a, LoserValue: "a"},
+		{Type: OutputTypeExtendedResource, Key: "er-1", Winner: b, WinnerValue: "2", Loser: a, LoserValue: "1"},
+	}, conflicts)
+	assert.False(t, conflicts[0].ResolvedByPriority())
+	assert.Equal(t, `label "label-2": value "b" from nfr-b/rule-1 overrides value "a" from nfr-a/rule-1 (by evaluation order)`, conflicts[0].String())
+
+	assert.Len(t, m.Conflicts, 4)
+	assert.Equal(t, map[string]string{"label-1": "c", "label-2": "b", "label-3": "c"}, m.Labels)
+	assert.Equal(t, map[string]string{"annotation-1": "a"}, m.Annotations)
+	assert.Equal(t, map[string]string{"er-1": "2"}, m.ExtendedResources)
+	assert.Equal(t, []corev1.Taint{
+		{Key: "taint-1", Value: "c", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "taint-1", Value: "a", Effect: corev1.TaintEffectNoExecute},
+	}, m.Taints)
+}
+
+func TestWithoutLostLabels(t *testing.T) {
+	a := RuleSource{NodeFeatureRule: "nfr-a", Rule: "rule-1", Priority: 10}
+	b := RuleSource{NodeFeatureRule: "nfr-b", Rule: "rule-1"}
+
+	m := NewOutputMerger()
+	m.Add(a, RuleOutput{Labels: map[string]string{"ns/label-1": "a"}})
+
+	out := RuleOutput{Labels: map[string]string{"label-1": "b", "label-2": "b"}}
+	key := func(k string) string { return "ns/" + k }
+	conflicts := m.Add(b, RuleOutput{Labels: map[string]string{"ns/label-1": "b", "ns/label-2": "b"}})
+
+	// Lost label is dropped
+	assert.Equal(t, map[string]string{"label-2": "b"}, WithoutLostLabels(out, b, conflicts, key).Labels)
+	// Output of the winning rule is returned as is
+	assert.Equal(t, out, WithoutLostLabels(out, a, conflicts, key))
+}
+
+func TestWithDefaultNs(t *testing.T) {
+	out := WithDefaultNs(RuleOutput{
+		Labels:            map[string]string{"label-1": "a", "ns/label-2": "b", "label-3": "c", "feature.node.kubernetes.io/label-3": "d"},
+		ExtendedResources: map[string]string{"er-1": "1"},
+		Annotations:       map[string]string{"annotation-1": "a"},
+	})
+	assert.Equal(t, map[string]string{
+		"feature.node.kubernetes.io/label-1": "a",
+		"ns/label-2":                         "b",
+		"feature.node.kubernetes.io/label-3": "d",
+	}, out.Labels)
+	assert.Equal(t, map[string]string{"feature.node.kubernetes.io/er-1": "1"}, out.ExtendedResources)
+	assert.Equal(t, map[string]string{"feature.node.kubernetes.io/annotation-1": "a"}, out.Annotations)
+
+	assert.Equal(t, "feature.node.kubernetes.io/label-1", DefaultNsLabelKey("label-1"))
+	assert.Equal(t, "ns/label-1", DefaultNsLabelKey("ns/label-1"))
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+// ParseRuleReference parses a rule reference of the after field of a rule,
+// in the format <nodefeaturerule-name>[/<rule-name>]. An empty rule name
+// refers to all rules of the NodeFeatureRule object.
+func ParseRuleReference(ref string) (nfrName, ruleName string, err error) {
+	nfrName, ruleName, hasRule := strings.Cut(ref, "/")
+	if nfrName == "" {
+		return "", "", fmt.Errorf("invalid rule reference %q, NodeFeatureRule name must not be empty", ref)
+	}
+	if hasRule && ruleName == "" {
+		return "", "", fmt.Errorf("invalid rule reference %q, rule name must not be empty", ref)
+	}
+	return nfrName, ruleName, nil
+}
+
+// SortNodeFeatureRules returns the NodeFeatureRule objects in evaluation
+// order. The objects are sorted topologically by the dependencies specified
+// in the after field of their rules, ties broken by name, i.e. objects without
+// dependencies are evaluated in alphabetical order. References to objects not
+// in the list are ignored. An error is returned if the dependencies contain
+// cycles, in which case the objects that could not be ordered are evaluated
+// last, in alphabetical order.
+func SortNodeFeatureRules(nfrs []*nfdv1alpha1.NodeFeatureRule) ([]*nfdv1alpha1.NodeFeatureRule, error) {
+	sorted := slices.Clone(nfrs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	names := make(map[string]struct{}, len(sorted))
+	for _, nfr := range sorted {
+		names[nfr.Name] = struct{}{}
+	}
+
+	deps := make(map[string]map[string]struct{}, len(sorted))
+	for _, nfr := range sorted {
+		d := make(map[string]struct{})
+		for _, r := range nfr.Spec.Rules {
+			for _, ref := range r.After {
+				name, _, err := ParseRuleReference(ref)
+				if _, ok := names[name]; err == nil && ok && name != nfr.Name {
+					d[name] = struct{}{}
+				}
+			}
+		}
+		deps[nfr.Name] = d
+	}
+
+	ret := make([]*nfdv1alpha1.NodeFeatureRule, 0, len(sorted))
+	done := make(map[string]struct{}, len(sorted))
+	ready := func(name string) bool {
+		for d := range deps[name] {
+			if _, ok := done[d]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	for progress := true; progress; {
+		progress = false
+		for _, nfr := range sorted {
+			if _, ok := done[nfr.Name]; !ok && ready(nfr.Name) {
+				ret = append(ret, nfr)
+				done[nfr.Name] = struct{}{}
+				progress = true
+				break
+			}
+		}
+	}
+
+	if len(ret) < len(sorted) {
+		var cyclic []string
+		for _, nfr := range sorted {
+			if _, ok := done[nfr.Name]; !ok {
+				cyclic = append(cyclic, nfr.Name)
+				ret = append(ret, nfr)
+			}
+		}
+		return ret, fmt.Errorf("dependency cycle between NodeFeatureRule objects: %s", strings.Join(cyclic, ", "))
+	}
+	return ret, nil
+}
+
+// InsertBackrefs feeds the labels and vars output by a rule of the named
+// NodeFeatureRule object back to the input features for subsequent rules to
+// match against. The output is available in the "rule.matched" feature and in
+// the namespaced "rule.<nodefeaturerule-name>" feature.
+func InsertBackrefs(features *nfdv1alpha1.Features, nfrName string, out RuleOutput) {
+	for _, name := range []string{nfdv1alpha1.RuleBackrefFeature, nfrName} {
+		if name == "" {
+			continue
+		}
+		features.InsertAttributeFeatures(nfdv1alpha1.RuleBackrefDomain, name, out.Labels)
+		features.InsertAttributeFeatures(nfdv1alpha1.RuleBackrefDomain, name, out.Vars)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+func newTestNodeFeatureRule(name string, after ...string) *nfdv1alpha1.NodeFeatureRule {
+	return &nfdv1alpha1.NodeFeatureRule{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+			Rules: []nfdv1alpha1.Rule{{Name: "rule-1", After: after}},
+		},
+	}
+}
+
+func nodeFeatureRuleNames(nfrs []*nfdv1alpha1.NodeFeatureRule) []string {
+	names := make([]string, len(nfrs))
+	for i, nfr := range nfrs {
+		names[i] = nfr.Name
+	}
+	return names
+}
+
+func TestParseRuleReference(t *testing.T) {
+	nfr, rule, err := ParseRuleReference("nfr-1/rule-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "nfr-1", nfr)
+	assert.Equal(t, "rule-1", rule)
+
+	nfr, rule, err = ParseRuleReference("nfr-1")
+	assert.Nil(t, err)
+	assert.Equal(t, "nfr-1", nfr)
+	assert.Empty(t, rule)
+
+	_, _, err = ParseRuleReference("")
+	assert.Error(t, err)
+	_, _, err = ParseRuleReference("nfr-1/")
+	assert.Error(t, err)
+}
+
+func TestSortNodeFeatureRules(t *testing.T) {
+	// Without dependencies, objects are sorted by name
+	sorted, err := SortNodeFeatureRules([]*nfdv1alpha1.NodeFeatureRule{
+		newTestNodeFeatureRule("c"),
+		newTestNodeFeatureRule("a"),
+		newTestNodeFeatureRule("b"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, nodeFeatureRuleNames(sorted))
+
+	// Dependencies take precedence over names, references to non-existent
+	// objects and to the object itself are ignored
+	sorted, err = SortNodeFeatureRules([]*nfdv1alpha1.NodeFeatureRule{
+		newTestNodeFeatureRule("a", "c/rule-1", "non-existent"),
+		newTestNodeFeatureRule("b"),
+		newTestNodeFeatureRule("c", "d", "c/rule-1"),
+		newTestNodeFeatureRule("d"),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "d", "c", "a"}, nodeFeatureRuleNames(sorted))
+
+	// Objects in a cycle are evaluated last
+	sorted, err = SortNodeFeatureRules([]*nfdv1alpha1.NodeFeatureRule{
+		newTestNodeFeatureRule("a", "b"),
+		newTestNodeFeatureRule("b", "a"),
+		newTestNodeFeatureRule("c"),
+		newTestNodeFeatureRule("d", "a"),
+	})
+	assert.ErrorContains(t, err, "dependency cycle between NodeFeatureRule objects: a, b, d")
+	assert.Equal(t, []string{"c", "a", "b", "d"}, nodeFeatureRuleNames(sorted))
+}
+
+func TestInsertBackrefs(t *testing.T) {
+	f := nfdv1alpha1.NewFeatures()
+	InsertBackrefs(f, "nfr-1", RuleOutput{
+		Labels: map[string]string{"label-1": "true"},
+		Vars:   map[string]string{"var-1": "val-1"},
+	})
+
+	expected := map[string]string{"label-1": "true", "var-1": "val-1"}
+	assert.Equal(t, expected, f.Attributes["rule.matched"].Elements)
+	assert.Equal(t, expected, f.Attributes["rule.nfr-1"].Elements)
+}
@@ -18,12 +18,15 @@ package nodefeaturerule
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"sort"
 	"strconv"
 	strings "strings"
+	"unicode"
 
 	"golang.org/x/exp/maps"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
@@ -43,122 +46,304 @@ var matchOps = map[nfdv1alpha1.MatchOp]struct{}{
 	nfdv1alpha1.MatchIsFalse:      {},
 }
 
+// quantityRegexp matches a number in the Kubernetes resource quantity format,
+// followed by an optional suffix and unit, e.g. "Hz" in "3.2GHz" or "b/s" in
+// "100000Mb/s".
+var quantityRegexp = regexp.MustCompile(`^([+-]?(?:[0-9]+(?:\.[0-9]*)?|\.[0-9]+)(?:[eE][+-]?[0-9]+)?)\s*([A-Za-z/%]*)$`)
+
+// quantitySuffixes are the suffixes of the Kubernetes resource quantity format
+// accepted in front of a unit. The longest suffixes come first. "K" is
+// accepted as an alias of "k", as in "KB" or "Kbit/s".
+var quantitySuffixes = []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei", "k", "K", "M", "G", "T", "P", "E"}
+
 // evaluateMatchExpression evaluates the MatchExpression against a single input value.
 func evaluateMatchExpression(m *nfdv1alpha1.MatchExpression, valid bool, value interface{}) (bool, error) {
+	return compileMatchExpression(m).evaluate(valid, value)
+}
+
+// compiledExpression is the pre-processed form of a MatchExpression, with
+// the regular expressions and numbers in the value field parsed. It is
+// read-only after creation and thus safe for concurrent use.
+type compiledExpression struct {
+	*nfdv1alpha1.MatchExpression
+
+	regexps []*regexp.Regexp
+	ints    []int
+	floats  []float64
+	// err is the validation error of the expression. Errors of Any, Exists
+	// and DoesNotExist are always reported, errors of other ops only when
+	// evaluating against an existing value.
+	err error
+}
+
+// compileMatchExpression validates and pre-processes a MatchExpression.
+func compileMatchExpression(m *nfdv1alpha1.MatchExpression) *compiledExpression {
+	c := &compiledExpression{MatchExpression: m}
+
 	if _, ok := matchOps[m.Op]; !ok {
-		return false, fmt.Errorf("invalid Op %q", m.Op)
+		c.err = fmt.Errorf("invalid Op %q", m.Op)
+		return c
+	}
+
+	switch m.Type {
+	case "":
+	case nfdv1alpha1.MatchValueTypeInt, nfdv1alpha1.MatchValueTypeFloat, nfdv1alpha1.MatchValueTypeQuantity:
+		if m.Op != nfdv1alpha1.MatchGt && m.Op != nfdv1alpha1.MatchLt && m.Op != nfdv1alpha1.MatchGtLt {
+			c.err = fmt.Errorf("invalid expression, 'type' field must be empty for Op %q (have %q)", m.Op, m.Type)
+			return c
+		}
+	default:
+		c.err = fmt.Errorf("invalid Type %q", m.Type)
+		return c
 	}
 
 	switch m.Op {
-	case nfdv1alpha1.MatchAny:
+	case nfdv1alpha1.MatchAny, nfdv1alpha1.MatchExists, nfdv1alpha1.MatchDoesNotExist, nfdv1alpha1.MatchIsTrue, nfdv1alpha1.MatchIsFalse:
 		if len(m.Value) != 0 {
-			return false, fmt.Errorf("invalid expression, 'value' field must be empty for Op %q (have %v)", m.Op, m.Value)
+			c.err = fmt.Errorf("invalid expression, 'value' field must be empty for Op %q (have %v)", m.Op, m.Value)
 		}
-		return true, nil
-	case nfdv1alpha1.MatchExists:
-		if len(m.Value) != 0 {
-			return false, fmt.Errorf("invalid expression, 'value' field must be empty for Op %q (have %v)", m.Op, m.Value)
+	case nfdv1alpha1.MatchIn, nfdv1alpha1.MatchNotIn:
+		if len(m.Value) == 0 {
+			c.err = fmt.Errorf("invalid expression, 'value' field must be non-empty for Op %q", m.Op)
 		}
-		return valid, nil
-	case nfdv1alpha1.MatchDoesNotExist:
-		if len(m.Value) != 0 {
-			return false, fmt.Errorf("invalid expression, 'value' field must be empty for Op %q (have %v)", m.Op, m.Value)
+	case nfdv1alpha1.MatchInRegexp:
+		if len(m.Value) == 0 {
+			c.err = fmt.Errorf("invalid expression, 'value' field must be non-empty for Op %q", m.Op)
+			return c
+		}
+		c.regexps = make([]*regexp.Regexp, len(m.Value))
+		for i, v := range m.Value {
+			re, err := regexp.Compile(v)
+			if err != nil {
+				c.err = fmt.Errorf("invalid expressiom, 'value' field must only contain valid regexps for Op %q (have %v)", m.Op, m.Value)
+				return c
+			}
+			c.regexps[i] = re
+		}
+	case nfdv1alpha1.MatchGt, nfdv1alpha1.MatchLt:
+		if len(m.Value) != 1 {
+			c.err = fmt.Errorf("invalid expression, 'value' field must contain exactly one element for Op %q (have %v)", m.Op, m.Value)
+			return c
+		}
+		c.err = c.parseNumbers()
+	case nfdv1alpha1.MatchGtLt:
+		if len(m.Value) != 2 {
+			c.err = fmt.Errorf("invalid expression, value' field must contain exactly two elements for Op %q (have %v)", m.Op, m.Value)
+			return c
+		}
+		if c.err = c.parseNumbers(); c.err == nil && !c.less(0, 1) {
+			c.err = fmt.Errorf("invalid expression, value[0] must be less than Value[1] for Op %q (have %v)", m.Op, m.Value)
 		}
-		return !valid, nil
 	}
+	return c
+}
 
-	if valid {
-		value := fmt.Sprintf("%v", value)
-		switch m.Op {
-		case nfdv1alpha1.MatchIn:
-			if len(m.Value) == 0 {
-				return false, fmt.Errorf("invalid expression, 'value' field must be non-empty for Op %q", m.Op)
-			}
-			for _, v := range m.Value {
-				if value == v {
-					return true, nil
-				}
-			}
-		case nfdv1alpha1.MatchNotIn:
-			if len(m.Value) == 0 {
-				return false, fmt.Errorf("invalid expression, 'value' field must be non-empty for Op %q", m.Op)
-			}
-			for _, v := range m.Value {
-				if value == v {
-					return false, nil
-				}
-			}
-			return true, nil
-		case nfdv1alpha1.MatchInRegexp:
-			if len(m.Value) == 0 {
-				return false, fmt.Errorf("invalid expression, 'value' field must be non-empty for Op %q", m.Op)
-			}
-			valueRe := make([]*regexp.Regexp, len(m.Value))
-			for i, v := range m.Value {
-				re, err := regexp.Compile(v)
-				if err != nil {
-					return false, fmt.Errorf("invalid expressiom, 'value' field must only contain valid regexps for Op %q (have %v)", m.Op, m.Value)
-				}
-				valueRe[i] = re
-			}
-			for _, re := range valueRe {
-				if re.MatchString(value) {
-					return true, nil
-				}
-			}
-		case nfdv1alpha1.MatchGt, nfdv1alpha1.MatchLt:
-			if len(m.Value) != 1 {
-				return false, fmt.Errorf("invalid expression, 'value' field must contain exactly one element for Op %q (have %v)", m.Op, m.Value)
-			}
+// isInt returns true if the expression compares integer numbers.
+func (c *compiledExpression) isInt() bool {
+	return c.Type == "" || c.Type == nfdv1alpha1.MatchValueTypeInt
+}
 
-			l, err := strconv.Atoi(value)
+func (c *compiledExpression) parseNumbers() error {
+	if !c.isInt() {
+		c.floats = make([]float64, len(c.Value))
+		for i, v := range c.Value {
+			f, err := parseFloat(c.Type, v)
 			if err != nil {
-				return false, fmt.Errorf("not a number %q", value)
+				return fmt.Errorf("%w in %v", err, c.MatchExpression)
 			}
-			r, err := strconv.Atoi(m.Value[0])
-			if err != nil {
-				return false, fmt.Errorf("not a number %q in %v", m.Value[0], m)
+			c.floats[i] = f
+		}
+		return nil
+	}
+
+	c.ints = make([]int, len(c.Value))
+	for i, v := range c.Value {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("not a number %q in %v", v, c.MatchExpression)
+		}
+		c.ints[i] = n
+	}
+	return nil
+}
+
+// less returns true if the ith value of the expression is less than the jth.
+func (c *compiledExpression) less(i, j int) bool {
+	if c.isInt() {
+		return c.ints[i] < c.ints[j]
+	}
+	return c.floats[i] < c.floats[j]
+}
+
+// ParseNumber parses a value of the Gt, Lt and GtLt operators according to
+// the given value type. An empty type means Int.
+func ParseNumber(t nfdv1alpha1.MatchValueType, v string) (float64, error) {
+	switch t {
+	case "", nfdv1alpha1.MatchValueTypeInt:
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("not a number %q", v)
+		}
+		return float64(n), nil
+	case nfdv1alpha1.MatchValueTypeFloat, nfdv1alpha1.MatchValueTypeQuantity:
+		return parseFloat(t, v)
+	}
+	return 0, fmt.Errorf("invalid Type %q", t)
+}
+
+// parseFloat parses a decimal number or a resource quantity, depending on the
+// value type.
+func parseFloat(t nfdv1alpha1.MatchValueType, v string) (float64, error) {
+	if t == nfdv1alpha1.MatchValueTypeQuantity {
+		m := quantityRegexp.FindStringSubmatch(v)
+		if m == nil {
+			return 0, fmt.Errorf("not a quantity %q", v)
+		}
+		suffix, err := splitQuantitySuffix(m[2])
+		if err != nil {
+			return 0, fmt.Errorf("not a quantity %q: %w", v, err)
+		}
+		q, err := resource.ParseQuantity(m[1] + suffix)
+		if err != nil {
+			return 0, fmt.Errorf("not a quantity %q: %w", v, err)
+		}
+		return q.AsApproximateFloat64(), nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("not a decimal number %q", v)
+	}
+	return f, nil
+}
+
+// splitQuantitySuffix returns the quantity suffix of the part following the
+// number of a quantity, dropping the unit. The sub-unit suffixes (n, u and m)
+// are only accepted without a unit, and a unit must not start with any other
+// letter that looks like an unsupported suffix, to avoid silently misreading
+// e.g. "512mb" or "8gb".
+func splitQuantitySuffix(s string) (string, error) {
+	switch s {
+	case "n", "u", "m":
+		return s, nil
+	}
+	for _, suffix := range quantitySuffixes {
+		if strings.HasPrefix(s, suffix) {
+			if suffix == "K" {
+				suffix = "k"
 			}
+			return suffix, nil
+		}
+	}
+	if s != "" && strings.ContainsRune("kmgtpeun", unicode.ToLower(rune(s[0]))) {
+		return "", fmt.Errorf("unknown suffix in %q", s)
+	}
+	return "", nil
+}
 
-			if (l < r && m.Op == nfdv1alpha1.MatchLt) || (l > r && m.Op == nfdv1alpha1.MatchGt) {
+// evaluate evaluates the compiled expression against a single input value.
+func (c *compiledExpression) evaluate(valid bool, value interface{}) (bool, error) {
+	switch c.Op {
+	case nfdv1alpha1.MatchAny, nfdv1alpha1.MatchExists, nfdv1alpha1.MatchDoesNotExist:
+		if c.err != nil {
+			return false, c.err
+		}
+		return c.Op == nfdv1alpha1.MatchAny || valid == (c.Op == nfdv1alpha1.MatchExists), nil
+	}
+	if _, ok := matchOps[c.Op]; !ok {
+		return false, c.err
+	}
+
+	if !valid {
+		return false, nil
+	}
+	if c.err != nil {
+		return false, c.err
+	}
+
+	v := fmt.Sprintf("%v", value)
+	switch c.Op {
+	case nfdv1alpha1.MatchIn:
+		for _, val := range c.Value {
+			if v == val {
 				return true, nil
 			}
-		case nfdv1alpha1.MatchGtLt:
-			if len(m.Value) != 2 {
-				return false, fmt.Errorf("invalid expression, value' field must contain exactly two elements for Op %q (have %v)", m.Op, m.Value)
-			}
-			v, err := strconv.Atoi(value)
-			if err != nil {
-				return false, fmt.Errorf("not a number %q", value)
-			}
-			lr := make([]int, 2)
-			for i := 0; i < 2; i++ {
-				lr[i], err = strconv.Atoi(m.Value[i])
-				if err != nil {
-					return false, fmt.Errorf("not a number %q in %v", m.Value[i], m)
-				}
+		}
+	case nfdv1alpha1.MatchNotIn:
+		for _, val := range c.Value {
+			if v == val {
+				return false, nil
 			}
-			if lr[0] >= lr[1] {
-				return false, fmt.Errorf("invalid expression, value[0] must be less than Value[1] for Op %q (have %v)", m.Op, m.Value)
+		}
+		return true, nil
+	case nfdv1alpha1.MatchInRegexp:
+		for _, re := range c.regexps {
+			if re.MatchString(v) {
+				return true, nil
 			}
-			return v > lr[0] && v < lr[1], nil
-		case nfdv1alpha1.MatchIsTrue:
-			if len(m.Value) != 0 {
-				return false, fmt.Errorf("invalid expression, 'value' field must be empty for Op %q (have %v)", m.Op, m.Value)
+		}
+	case nfdv1alpha1.MatchGt, nfdv1alpha1.MatchLt, nfdv1alpha1.MatchGtLt:
+		if !c.isInt() {
+			f, err := parseFloat(c.Type, v)
+			if err != nil {
+				return false, err
 			}
-			return value == "true", nil
-		case nfdv1alpha1.MatchIsFalse:
-			if len(m.Value) != 0 {
-				return false, fmt.Errorf("invalid expression, 'value' field must be empty for Op %q (have %v)", m.Op, m.Value)
+			switch c.Op {
+			case nfdv1alpha1.MatchGt:
+				return f > c.floats[0], nil
+			case nfdv1alpha1.MatchLt:
+				return f < c.floats[0], nil
 			}
-			return value == "false", nil
-		default:
-			return false, fmt.Errorf("unsupported Op %q", m.Op)
+			return f > c.floats[0] && f < c.floats[1], nil
 		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false, fmt.Errorf("not a number %q", v)
+		}
+		switch c.Op {
+		case nfdv1alpha1.MatchGt:
+			return n > c.ints[0], nil
+		case nfdv1alpha1.MatchLt:
+			return n < c.ints[0], nil
+		}
+		return n > c.ints[0] && n < c.ints[1], nil
+	case nfdv1alpha1.MatchIsTrue:
+		return v == "true", nil
+	case nfdv1alpha1.MatchIsFalse:
+		return v == "false", nil
 	}
 	return false, nil
 }
 
+// compiledExpressions holds pre-processed MatchExpressions. A nil value is
+// valid and causes expressions to be compiled on the fly.
+type compiledExpressions map[*nfdv1alpha1.MatchExpression]*compiledExpression
+
+// get returns the compiled form of a MatchExpression.
+func (c compiledExpressions) get(m *nfdv1alpha1.MatchExpression) *compiledExpression {
+	if e, ok := c[m]; ok {
+		return e
+	}
+	return compileMatchExpression(m)
+}
+
+// addSet compiles all expressions of a MatchExpressionSet.
+func (c compiledExpressions) addSet(m *nfdv1alpha1.MatchExpressionSet) {
+	if m == nil {
+		return
+	}
+	for _, e := range *m {
+		c.add(e)
+	}
+}
+
+// add compiles a MatchExpression.
+func (c compiledExpressions) add(m *nfdv1alpha1.MatchExpression) {
+	if m != nil {
+		c[m] = compileMatchExpression(m)
+	}
+}
+
 // evaluateMatchExpressionKeys evaluates the MatchExpression against a set of keys.
 func evaluateMatchExpressionKeys(m *nfdv1alpha1.MatchExpression, name string, keys map[string]nfdv1alpha1.Nil) (bool, error) {
 	matched := false
@@ -187,8 +372,12 @@ func evaluateMatchExpressionKeys(m *nfdv1alpha1.MatchExpression, name string, ke
 
 // evaluateMatchExpressionValues evaluates the MatchExpression against a set of key-value pairs.
 func evaluateMatchExpressionValues(m *nfdv1alpha1.MatchExpression, name string, values map[string]string) (bool, error) {
+	return compiledExpressions(nil).evaluateValues(m, name, values)
+}
+
+func (c compiledExpressions) evaluateValues(m *nfdv1alpha1.MatchExpression, name string, values map[string]string) (bool, error) {
 	v, ok := values[name]
-	matched, err := evaluateMatchExpression(m, ok, v)
+	matched, err := c.get(m).evaluate(ok, v)
 	if err != nil {
 		return false, err
 	}
@@ -204,10 +393,15 @@ func evaluateMatchExpressionValues(m *nfdv1alpha1.MatchExpression, name string,
 
 // MatchKeyNames evaluates the MatchExpression against names of a set of key features.
 func MatchKeyNames(m *nfdv1alpha1.MatchExpression, keys map[string]nfdv1alpha1.Nil) (bool, []MatchedElement, error) {
+	return compiledExpressions(nil).matchKeyNames(m, keys)
+}
+
+func (c compiledExpressions) matchKeyNames(m *nfdv1alpha1.MatchExpression, keys map[string]nfdv1alpha1.Nil) (bool, []MatchedElement, error) {
 	ret := []MatchedElement{}
+	e := c.get(m)
 
 	for k := range keys {
-		if match, err := evaluateMatchExpression(m, true, k); err != nil {
+		if match, err := e.evaluate(true, k); err != nil {
 			return false, nil, err
 		} else if match {
 			ret = append(ret, MatchedElement{"Name": k})
@@ -240,10 +434,15 @@ func MatchKeyNames(m *nfdv1alpha1.MatchExpression, keys map[string]nfdv1alpha1.N
 
 // MatchValueNames evaluates the MatchExpression against names of a set of value features.
 func MatchValueNames(m *nfdv1alpha1.MatchExpression, values map[string]string) (bool, []MatchedElement, error) {
+	return compiledExpressions(nil).matchValueNames(m, values)
+}
+
+func (c compiledExpressions) matchValueNames(m *nfdv1alpha1.MatchExpression, values map[string]string) (bool, []MatchedElement, error) {
 	ret := []MatchedElement{}
+	e := c.get(m)
 
 	for k, v := range values {
-		if match, err := evaluateMatchExpression(m, true, k); err != nil {
+		if match, err := e.evaluate(true, k); err != nil {
 			return false, nil, err
 		} else if match {
 			ret = append(ret, MatchedElement{"Name": k, "Value": v})
@@ -272,10 +471,14 @@ func MatchValueNames(m *nfdv1alpha1.MatchExpression, values map[string]string) (
 // MatchInstanceAttributeNames evaluates the MatchExpression against a set of
 // instance features, matching against the names of their attributes.
 func MatchInstanceAttributeNames(m *nfdv1alpha1.MatchExpression, instances []nfdv1alpha1.InstanceFeature) ([]MatchedElement, error) {
+	return compiledExpressions(nil).matchInstanceAttributeNames(m, instances)
+}
+
+func (c compiledExpressions) matchInstanceAttributeNames(m *nfdv1alpha1.MatchExpression, instances []nfdv1alpha1.InstanceFeature) ([]MatchedElement, error) {
 	ret := []MatchedElement{}
 
 	for _, i := range instances {
-		if match, _, err := MatchValueNames(m, i.Attributes); err != nil {
+		if match, _, err := c.matchValueNames(m, i.Attributes); err != nil {
 			return nil, err
 		} else if match {
 			ret = append(ret, i.Attributes)
@@ -324,10 +527,14 @@ func MatchValues(m *nfdv1alpha1.MatchExpressionSet, values map[string]string) (b
 // pairs and returns all matched key-value pairs. Note that an empty
 // MatchExpressionSet returns a match with an empty slice of matched features.
 func MatchGetValues(m *nfdv1alpha1.MatchExpressionSet, values map[string]string) (bool, []MatchedElement, error) {
+	return compiledExpressions(nil).matchGetValues(m, values)
+}
+
+func (c compiledExpressions) matchGetValues(m *nfdv1alpha1.MatchExpressionSet, values map[string]string) (bool, []MatchedElement, error) {
 	ret := make([]MatchedElement, 0, len(*m))
 
 	for n, e := range *m {
-		match, err := evaluateMatchExpressionValues(e, n, values)
+		match, err := c.evaluateValues(e, n, values)
 		if err != nil {
 			return false, nil, err
 		}
@@ -354,10 +561,14 @@ func MatchInstances(m *nfdv1alpha1.MatchExpressionSet, instances []nfdv1alpha1.I
 // (attributes). A slice containing all matching instances is returned. An
 // empty (non-nil) slice is returned if no matching instances were found.
 func MatchGetInstances(m *nfdv1alpha1.MatchExpressionSet, instances []nfdv1alpha1.InstanceFeature) ([]MatchedElement, error) {
+	return compiledExpressions(nil).matchGetInstances(m, instances)
+}
+
+func (c compiledExpressions) matchGetInstances(m *nfdv1alpha1.MatchExpressionSet, instances []nfdv1alpha1.InstanceFeature) ([]MatchedElement, error) {
 	ret := []MatchedElement{}
 
 	for _, i := range instances {
-		if match, err := MatchValues(m, i.Attributes); err != nil {
+		if match, _, err := c.matchGetValues(m, i.Attributes); err != nil {
 			return nil, err
 		} else if match {
 			ret = append(ret, i.Attributes)
@@ -33,6 +33,7 @@ func TestEvaluateMatchExpression(t *testing.T) {
 	type TC struct {
 		name   string
 		op     nfdv1alpha1.MatchOp
+		typ    nfdv1alpha1.MatchValueType
 		values V
 		input  interface{}
 		valid  bool
@@ -79,6 +80,27 @@ func TestEvaluateMatchExpression(t *testing.T) {
 		{name: "MatchGtLt-3", op: nfdv1alpha1.MatchGtLt, values: V{"1", "10"}, input: "10", valid: true, result: assert.False},
 		{name: "MatchGtLt-4", op: nfdv1alpha1.MatchGtLt, values: V{"1", "10"}, input: "2", valid: true, result: assert.True},
 
+		{name: "MatchGt-Int-1", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeInt, values: V{"2"}, input: 3, valid: true, result: assert.True},
+
+		{name: "MatchGt-Float-1", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"2.5"}, input: "2.5", valid: true, result: assert.False},
+		{name: "MatchGt-Float-2", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"2.5"}, input: "2.51", valid: true, result: assert.True},
+		{name: "MatchGt-Float-3", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"-1e-3"}, input: 0, valid: true, result: assert.True},
+		{name: "MatchLt-Float-1", op: nfdv1alpha1.MatchLt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"2"}, input: 1.5, valid: true, result: assert.True},
+		{name: "MatchGtLt-Float-1", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"0.5", "1.5"}, input: "1.0", valid: true, result: assert.True},
+		{name: "MatchGtLt-Float-2", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"0.5", "1.5"}, input: "1.5", valid: true, result: assert.False},
+
+		{name: "MatchGt-Quantity-1", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"16Gi"}, input: "16Gi", valid: true, result: assert.False},
+		{name: "MatchGt-Quantity-2", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"16Gi"}, input: "17179869185", valid: true, result: assert.True},
+		{name: "MatchGt-Quantity-3", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"3GHz"}, input: "3.2GHz", valid: true, result: assert.True},
+		{name: "MatchGt-Quantity-4", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"10G"}, input: "100000Mb/s", valid: true, result: assert.True},
+		{name: "MatchLt-Quantity-1", op: nfdv1alpha1.MatchLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1Gi"}, input: "512Mi", valid: true, result: assert.True},
+		{name: "MatchLt-Quantity-2", op: nfdv1alpha1.MatchLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1"}, input: "500m", valid: true, result: assert.True},
+		{name: "MatchGtLt-Quantity-1", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1k", "1Ki"}, input: "1010 B", valid: true, result: assert.True},
+		{name: "MatchGtLt-Quantity-2", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1k", "1Ki"}, input: "2.5", valid: false, result: assert.False},
+		{name: "MatchGtLt-Quantity-3", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"7k", "9k"}, input: "8KB", valid: true, result: assert.True},
+		{name: "MatchGt-Quantity-5", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"9999"}, input: "10Kbit/s", valid: true, result: assert.True},
+		{name: "MatchGt-Quantity-6", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"8k"}, input: "8KiB", valid: true, result: assert.True},
+
 		{name: "MatchIsTrue-1", op: nfdv1alpha1.MatchIsTrue, input: true, valid: false, result: assert.False},
 		{name: "MatchIsTrue-2", op: nfdv1alpha1.MatchIsTrue, input: true, valid: true, result: assert.True},
 		{name: "MatchIsTrue-3", op: nfdv1alpha1.MatchIsTrue, input: false, valid: true, result: assert.False},
@@ -90,7 +112,7 @@ func TestEvaluateMatchExpression(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			me := &nfdv1alpha1.MatchExpression{Op: tc.op, Value: tc.values}
+			me := &nfdv1alpha1.MatchExpression{Op: tc.op, Type: tc.typ, Value: tc.values}
 			res, err := evaluateMatchExpression(me, tc.valid, tc.input)
 			tc.result(t, res)
 			assert.Nil(t, err)
@@ -129,6 +151,21 @@ func TestEvaluateMatchExpression(t *testing.T) {
 		{name: "MatchGtLt-err-5", op: nfdv1alpha1.MatchGtLt, values: V{"a", "2"}, input: "1"},
 		{name: "MatchGtLt-err-6", op: nfdv1alpha1.MatchGtLt, values: V{"1", "10"}, input: "1.0"},
 
+		{name: "MatchGt-Float-err-1", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"1Gi"}, input: "1"},
+		{name: "MatchGt-Float-err-2", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"1"}, input: "2GHz"},
+		{name: "MatchGt-Float-err-3", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"1"}, input: "NaN"},
+		{name: "MatchGtLt-Float-err-1", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeFloat, values: V{"1.5", "1.5"}, input: "1.5"},
+
+		{name: "MatchGt-Quantity-err-1", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"a"}, input: "1"},
+		{name: "MatchLt-Quantity-err-1", op: nfdv1alpha1.MatchLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1Gi"}, input: "1.2.3"},
+		{name: "MatchGtLt-Quantity-err-1", op: nfdv1alpha1.MatchGtLt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1Gi", "1G"}, input: "1"},
+		{name: "MatchGt-Quantity-err-2", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1"}, input: "512mb"},
+		{name: "MatchGt-Quantity-err-3", op: nfdv1alpha1.MatchGt, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"8gb"}, input: "1"},
+
+		{name: "MatchIn-Quantity-err-1", op: nfdv1alpha1.MatchIn, typ: nfdv1alpha1.MatchValueTypeQuantity, values: V{"1"}, input: "1"},
+		{name: "MatchIn-Int-err-1", op: nfdv1alpha1.MatchIn, typ: nfdv1alpha1.MatchValueTypeInt, values: V{"1"}, input: "1"},
+		{name: "invalid-type-err", op: nfdv1alpha1.MatchGt, typ: "non-existent-type", values: V{"1"}, input: "2"},
+
 		{name: "MatchIsTrue-err-1", op: nfdv1alpha1.MatchIsTrue, values: V{"1"}, input: "true"},
 
 		{name: "MatchIsFalse-err-1", op: nfdv1alpha1.MatchIsFalse, values: V{"1", "2"}, input: "false"},
@@ -138,7 +175,7 @@ func TestEvaluateMatchExpression(t *testing.T) {
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			me := &nfdv1alpha1.MatchExpression{Op: tc.op, Value: tc.values}
+			me := &nfdv1alpha1.MatchExpression{Op: tc.op, Type: tc.typ, Value: tc.values}
 			res, err := evaluateMatchExpression(me, true, tc.input)
 			assert.False(t, res)
 			assert.NotNil(t, err)
@@ -0,0 +1,229 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+// forEachInstanceTemplates returns the key and value templates of the
+// forEachInstance output of the rule, keyed by field name.
+func forEachInstanceTemplates(fe *nfdv1alpha1.ForEachInstance) map[string]string {
+	ret := make(map[string]string)
+	if fe == nil {
+		return ret
+	}
+	for field, m := range map[string]map[string]string{
+		"Labels":            fe.Labels,
+		"Annotations":       fe.Annotations,
+		"ExtendedResources": fe.ExtendedResources,
+	} {
+		for k, v := range m {
+			ret[forEachInstanceField(field, k, false)] = k
+			ret[forEachInstanceField(field, k, true)] = v
+		}
+	}
+	return ret
+}
+
+// forEachInstanceField returns the name used for the template of a key or
+// value of the forEachInstance output in error messages.
+func forEachInstanceField(field, key string, value bool) string {
+	name := fmt.Sprintf("ForEachInstance.%s[%q]", field, key)
+	if value {
+		name += ".value"
+	}
+	return name
+}
+
+// appendForEachInstances appends the instances of the forEachInstance feature
+// found in the matched features to a list of instances. The same instance
+// matched by multiple terms of the rule is only included once.
+func (c *CompiledRule) appendForEachInstances(instances []MatchedElement, in matchedFeatures) []MatchedElement {
+	fe := c.rule.ForEachInstance
+	if fe == nil {
+		return instances
+	}
+	dom, nam, _ := strings.Cut(fe.Feature, ".")
+	for _, e := range in[dom][nam] {
+		// Instances are identified by their attribute map, which is shared by
+		// the matched elements of the same instance
+		if !slices.ContainsFunc(instances, func(o MatchedElement) bool {
+			return reflect.ValueOf(o).Pointer() == reflect.ValueOf(e).Pointer()
+		}) {
+			instances = append(instances, e)
+		}
+	}
+	return instances
+}
+
+// executeForEachInstance expands the forEachInstance output of the rule for
+// each of the matched instances, storing the expanded data in the rule output.
+func (c *CompiledRule) executeForEachInstance(instances []MatchedElement, out *RuleOutput) error {
+	fe := c.rule.ForEachInstance
+	if fe == nil || len(instances) == 0 {
+		return nil
+	}
+
+	labels, err := c.expandForEachInstance("Labels", fe.Labels, instances, toLabelValue, nil)
+	if err != nil {
+		return err
+	}
+	annotations, err := c.expandForEachInstance("Annotations", fe.Annotations, instances, nil, nil)
+	if err != nil {
+		return err
+	}
+	extendedResources, err := c.expandForEachInstance("ExtendedResources", fe.ExtendedResources, instances, nil, addQuantities)
+	if err != nil {
+		return err
+	}
+
+	maps.Copy(out.Labels, labels)
+	maps.Copy(out.Annotations, annotations)
+	maps.Copy(out.ExtendedResources, extendedResources)
+	return nil
+}
+
+// expandForEachInstance expands the key and value templates of one output
+// field for each instance. Keys are sanitized, and values too if a sanitize
+// function is given. Values of the same key are merged with the merge
+// function if given, otherwise conflicting values get a numeric suffix added
+// to the key.
+func (c *CompiledRule) expandForEachInstance(field string, tmpls map[string]string, instances []MatchedElement, sanitize func(interface{}) string, merge func(a, b string) (string, bool)) (map[string]string, error) {
+	out := make(map[string]string)
+	if len(tmpls) == 0 {
+		return out, nil
+	}
+
+	keys := make([]string, 0, len(tmpls))
+	for k := range tmpls {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, instance := range instances {
+		for _, key := range keys {
+			k, err := c.expandForEachInstanceTemplate(forEachInstanceField(field, key, false), key, instance)
+			if err != nil {
+				return nil, err
+			}
+			v, err := c.expandForEachInstanceTemplate(forEachInstanceField(field, key, true), tmpls[key], instance)
+			if err != nil {
+				return nil, err
+			}
+
+			k = toQualifiedName(k)
+			if k == "" {
+				klog.V(2).InfoS("skipping empty key of forEachInstance output", "ruleName", c.rule.Name, "field", field, "key", key)
+				continue
+			}
+			if sanitize != nil {
+				v = sanitize(v)
+			}
+			insertForEachInstanceOutput(out, k, v, merge)
+		}
+	}
+	return out, nil
+}
+
+func (c *CompiledRule) expandForEachInstanceTemplate(field, tmpl string, instance MatchedElement) (string, error) {
+	th, err := c.template(field, tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", field, err)
+	}
+	expanded, err := th.execute(instance)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand %s: %w", field, err)
+	}
+	return strings.TrimSpace(expanded), nil
+}
+
+// insertForEachInstanceOutput inserts a key-value pair into the output,
+// resolving conflicts with existing keys.
+func insertForEachInstanceOutput(out map[string]string, k, v string, merge func(a, b string) (string, bool)) {
+	old, ok := out[k]
+	if ok && merge != nil {
+		if merged, ok := merge(old, v); ok {
+			out[k] = merged
+			return
+		}
+	}
+	if !ok || old == v {
+		out[k] = v
+		return
+	}
+	for n := 2; ; n++ {
+		sk := suffixedName(k, n)
+		if old, ok := out[sk]; !ok || old == v {
+			out[sk] = v
+			return
+		}
+	}
+}
+
+// toQualifiedName sanitizes the name part of a (possibly prefixed) key into
+// a valid qualified name. The prefix is not modified.
+func toQualifiedName(key string) string {
+	prefix, name := "", key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix, name = key[:i+1], key[i+1:]
+	}
+	if name = toLabelValue(name); name == "" {
+		return ""
+	}
+	return prefix + name
+}
+
+// suffixedName adds a numeric suffix to the name part of a key, truncating
+// the name if needed to keep it within the maximum length.
+func suffixedName(key string, n int) string {
+	prefix, name := "", key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix, name = key[:i+1], key[i+1:]
+	}
+	suffix := fmt.Sprintf("-%d", n)
+	if len(name)+len(suffix) > k8svalidation.LabelValueMaxLength {
+		name = strings.TrimRight(name[:k8svalidation.LabelValueMaxLength-len(suffix)], "-_.")
+	}
+	return prefix + name + suffix
+}
+
+// addQuantities sums two quantities. It returns false if either of the
+// values is not a valid quantity.
+func addQuantities(a, b string) (string, bool) {
+	qa, err := resource.ParseQuantity(a)
+	if err != nil {
+		return "", false
+	}
+	qb, err := resource.ParseQuantity(b)
+	if err != nil {
+		return "", false
+	}
+	qa.Add(qb)
+	return qa.String(), true
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+func TestForEachInstance(t *testing.T) {
+	f := nfdv1alpha1.NewFeatures()
+	f.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{"major": "6"})
+	f.Instances["pci.device"] = nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "10de", "device": "20b0", "model": "A100 SXM4", "memory": "40Gi"}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "10de", "device": "20b0", "model": "A100 SXM4", "memory": "40Gi"}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "10de", "device": "20b2", "model": "A100 SXM4", "memory": "80Gi"}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "8086", "device": "56c0", "model": "Flex 170", "memory": "16Gi"}),
+	})
+
+	r := &nfdv1alpha1.Rule{
+		Name: "gpus",
+		ForEachInstance: &nfdv1alpha1.ForEachInstance{
+			Feature: "pci.device",
+			Labels: map[string]string{
+				"gpu-{{ .vendor }}-{{ .device }}": "true",
+				"example.com/{{ .model }}":        "{{ .memory }}",
+			},
+			Annotations: map[string]string{
+				"example.com/gpu-{{ .device }}": "{{ .model }} ({{ .memory }})",
+			},
+			ExtendedResources: map[string]string{
+				"example.com/gpu-{{ .vendor }}": "1",
+			},
+		},
+		MatchFeatures: nfdv1alpha1.FeatureMatcher{
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature:          "pci.device",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"vendor": newMatchExpression(nfdv1alpha1.MatchIn, "10de")},
+			},
+		},
+	}
+
+	expectedLabels := map[string]string{
+		"gpu-10de-20b0":         "true",
+		"gpu-10de-20b2":         "true",
+		"example.com/A100_SXM4": "40Gi",
+		// Conflicting value gets a numeric suffix
+		"example.com/A100_SXM4-2": "80Gi",
+	}
+	expectedAnnotations := map[string]string{
+		"example.com/gpu-20b0": "A100 SXM4 (40Gi)",
+		"example.com/gpu-20b2": "A100 SXM4 (80Gi)",
+	}
+	// Extended resources of the same key are summed
+	expectedExtendedResources := map[string]string{"example.com/gpu-10de": "3"}
+
+	for _, execute := range []func(*nfdv1alpha1.Features) (RuleOutput, error){
+		func(f *nfdv1alpha1.Features) (RuleOutput, error) { return Execute(r, f) },
+		CompileRule(r).Execute,
+	} {
+		m, err := execute(f)
+		assert.Nilf(t, err, "unexpected error: %v", err)
+		assert.Equal(t, expectedLabels, m.Labels)
+		assert.Equal(t, expectedAnnotations, m.Annotations)
+		assert.Equal(t, expectedExtendedResources, m.ExtendedResources)
+	}
+
+	// Instances matched by multiple terms are only expanded once
+	r.MatchAny = []nfdv1alpha1.MatchAnyElem{
+		{
+			MatchFeatures: nfdv1alpha1.FeatureMatcher{
+				nfdv1alpha1.FeatureMatcherTerm{
+					Feature:          "pci.device",
+					MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"vendor": newMatchExpression(nfdv1alpha1.MatchExists)},
+				},
+			},
+		},
+	}
+	m, err := Execute(r, f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.Equal(t, map[string]string{"example.com/gpu-10de": "3", "example.com/gpu-8086": "1"}, m.ExtendedResources)
+
+	// Static output takes precedence
+	r.MatchAny = nil
+	r.Labels = map[string]string{"gpu-10de-20b0": "false"}
+	m, err = Execute(r, f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.Equal(t, "false", m.Labels["gpu-10de-20b0"])
+
+	// No output if the feature is not matched
+	r.Labels = nil
+	r.MatchFeatures = nfdv1alpha1.FeatureMatcher{
+		nfdv1alpha1.FeatureMatcherTerm{
+			Feature:          "kernel.version",
+			MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"major": newMatchExpression(nfdv1alpha1.MatchExists)},
+		},
+	}
+	m, err = Execute(r, f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.True(t, m.Matched)
+	assert.Empty(t, m.Labels)
+
+	// Missing attributes are reported as errors
+	r.MatchFeatures[0] = nfdv1alpha1.FeatureMatcherTerm{
+		Feature:          "pci.device",
+		MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"vendor": newMatchExpression(nfdv1alpha1.MatchExists)},
+	}
+	r.ForEachInstance.Labels = map[string]string{"gpu-{{ .non_existent }}": "true"}
+	_, err = CompileRule(r).Execute(f)
+	assert.ErrorContains(t, err, `failed to expand ForEachInstance.Labels["gpu-{{ .non_existent }}"]`)
+
+	// Invalid templates are reported as errors
+	r.ForEachInstance.Labels = map[string]string{"gpu": "{{ .vendor"}
+	_, err = CompileRule(r).Execute(f)
+	assert.ErrorContains(t, err, `failed to parse ForEachInstance.Labels["gpu"].value`)
+}
+
+func TestToQualifiedName(t *testing.T) {
+	assert.Equal(t, "example.com/A100_SXM4__80GB", toQualifiedName("example.com/A100 SXM4 (80GB)"))
+	assert.Equal(t, "gpu-0", toQualifiedName("-gpu-0-"))
+	assert.Equal(t, "", toQualifiedName("example.com/--"))
+	assert.Len(t, toQualifiedName(strings.Repeat("a", 100)), 63)
+}
+
+func TestSuffixedName(t *testing.T) {
+	assert.Equal(t, "example.com/gpu-2", suffixedName("example.com/gpu", 2))
+	assert.Equal(t, strings.Repeat("a", 60)+"-10", suffixedName(strings.Repeat("a", 63), 10))
+}
@@ -17,12 +17,10 @@ limitations under the License.
 package nodefeaturerule
 
 import (
-	"bytes"
 	"fmt"
 	"maps"
 	"slices"
 	"strings"
-	"text/template"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
@@ -39,124 +37,272 @@ type RuleOutput struct {
 	Annotations       map[string]string
 	Vars              map[string]string
 	Taints            []corev1.Taint
+	// Matched is true if the rule matched the input features.
+	Matched bool
 }
 
 // Execute the rule against a set of input features.
 func Execute(r *nfdv1alpha1.Rule, features *nfdv1alpha1.Features) (RuleOutput, error) {
-	labels := make(map[string]string)
-	vars := make(map[string]string)
+	out, _, err := (&CompiledRule{rule: r}).execute(features)
+	return out, err
+}
+
+// CompiledRule is a pre-processed form of a Rule for repeated execution
+// against different sets of input features. The regular expressions and
+// integers of the match expressions and all templates of the rule are parsed
+// only once, in CompileRule. A CompiledRule is safe for concurrent use.
+// +k8s:deepcopy-gen=false
+type CompiledRule struct {
+	rule        *nfdv1alpha1.Rule
+	expressions compiledExpressions
+	templates   map[string]compiledTemplate
+}
+
+type compiledTemplate struct {
+	helper *templateHelper
+	err    error
+}
+
+// CompileRule pre-processes a rule. Validation errors of the rule are not
+// reported here but when the rule is executed, the same as with Execute. The
+// rule must not be modified after compilation.
+func CompileRule(r *nfdv1alpha1.Rule) *CompiledRule {
+	c := &CompiledRule{
+		rule:        r,
+		expressions: make(compiledExpressions),
+		templates:   make(map[string]compiledTemplate),
+	}
+
+	for i := range r.MatchAny {
+		c.expressions.addFeatureMatcher(&r.MatchAny[i].MatchFeatures)
+	}
+	c.expressions.addFeatureMatcher(&r.MatchFeatures)
+
+	for field, tmpl := range ruleTemplates(r) {
+		if tmpl != "" {
+			th, err := newTemplateHelper(tmpl)
+			c.templates[field] = compiledTemplate{helper: th, err: err}
+		}
+	}
+	return c
+}
+
+// Rule returns the rule the CompiledRule was created from.
+func (c *CompiledRule) Rule() *nfdv1alpha1.Rule {
+	return c.rule
+}
+
+// Execute the compiled rule against a set of input features.
+func (c *CompiledRule) Execute(features *nfdv1alpha1.Features) (RuleOutput, error) {
+	out, _, err := c.execute(features)
+	return out, err
+}
+
+// ExecuteWithTrace executes the rule against a set of input features, like
+// Execute, and additionally returns a trace of the rule evaluation.
+func ExecuteWithTrace(r *nfdv1alpha1.Rule, features *nfdv1alpha1.Features) (RuleOutput, *RuleTrace, error) {
+	return (&CompiledRule{rule: r}).ExecuteWithTrace(features)
+}
+
+// ExecuteWithTrace executes the compiled rule against a set of input
+// features, like Execute, and additionally returns a trace of the rule
+// evaluation.
+func (c *CompiledRule) ExecuteWithTrace(features *nfdv1alpha1.Features) (RuleOutput, *RuleTrace, error) {
+	trace := traceRule(c.rule, features)
+
+	out, matched, err := c.execute(features)
+	trace.Matched = matched
+	if err != nil {
+		trace.Error = err.Error()
+	}
+	return out, trace, err
+}
+
+func (c *CompiledRule) execute(features *nfdv1alpha1.Features) (RuleOutput, bool, error) {
+	r := c.rule
+	out := RuleOutput{
+		Labels:            make(map[string]string),
+		Vars:              make(map[string]string),
+		Annotations:       make(map[string]string),
+		ExtendedResources: make(map[string]string),
+	}
+	var instances []MatchedElement
 
 	if len(r.MatchAny) > 0 {
 		// Logical OR over the matchAny matchers
 		matched := false
 		for _, matcher := range r.MatchAny {
-			if isMatch, matches, err := evaluateMatchAnyElem(&matcher, features); err != nil {
-				return RuleOutput{}, err
+			if isMatch, matches, err := c.expressions.evaluateMatchAnyElem(&matcher, features); err != nil {
+				return RuleOutput{}, false, err
 			} else if isMatch {
 				matched = true
 				klog.V(4).InfoS("matchAny matched", "ruleName", r.Name, "matchedFeatures", utils.DelayedDumper(matches))
 
-				if r.LabelsTemplate == "" && r.VarsTemplate == "" {
+				if !hasTemplates(r) {
 					// there's no need to evaluate other matchers in MatchAny
 					// if there are no templates to be executed on them - so
 					// short-circuit and stop on first match here
 					break
 				}
 
-				if err := executeLabelsTemplate(r, matches, labels); err != nil {
-					return RuleOutput{}, err
-				}
-				if err := executeVarsTemplate(r, matches, vars); err != nil {
-					return RuleOutput{}, err
+				if err := c.executeTemplates(matches, &out); err != nil {
+					return RuleOutput{}, false, err
 				}
+				instances = c.appendForEachInstances(instances, matches)
 			}
 		}
 		if !matched {
 			klog.V(2).InfoS("rule did not match", "ruleName", r.Name)
-			return RuleOutput{}, nil
+			return RuleOutput{}, false, nil
 		}
 	}
 
 	if len(r.MatchFeatures) > 0 {
-		if isMatch, matches, err := evaluateFeatureMatcher(&r.MatchFeatures, features); err != nil {
-			return RuleOutput{}, err
+		if isMatch, matches, err := c.expressions.evaluateFeatureMatcher(&r.MatchFeatures, features); err != nil {
+			return RuleOutput{}, false, err
 		} else if !isMatch {
 			klog.V(2).InfoS("rule did not match", "ruleName", r.Name)
-			return RuleOutput{}, nil
+			return RuleOutput{}, false, nil
 		} else {
 			klog.V(4).InfoS("matchFeatures matched", "ruleName", r.Name, "matchedFeatures", utils.DelayedDumper(matches))
-			if err := executeLabelsTemplate(r, matches, labels); err != nil {
-				return RuleOutput{}, err
-			}
-			if err := executeVarsTemplate(r, matches, vars); err != nil {
-				return RuleOutput{}, err
+			if err := c.executeTemplates(matches, &out); err != nil {
+				return RuleOutput{}, false, err
 			}
+			instances = c.appendForEachInstances(instances, matches)
 		}
 	}
 
-	maps.Copy(labels, r.Labels)
-	maps.Copy(vars, r.Vars)
+	if err := c.executeForEachInstance(instances, &out); err != nil {
+		return RuleOutput{}, false, err
+	}
+
+	maps.Copy(out.Labels, r.Labels)
+	maps.Copy(out.Vars, r.Vars)
+	maps.Copy(out.Annotations, r.Annotations)
+	maps.Copy(out.ExtendedResources, r.ExtendedResources)
+	out.Taints = mergeTaints(out.Taints, r.Taints)
+	out.Matched = true
+
+	klog.V(2).InfoS("rule matched", "ruleName", r.Name, "ruleOutput", utils.DelayedDumper(out))
+	return out, true, nil
+}
+
+// hasTemplates returns true if the rule has any templates to be executed.
+func hasTemplates(r *nfdv1alpha1.Rule) bool {
+	return r.LabelsTemplate != "" || r.VarsTemplate != "" || r.AnnotationsTemplate != "" ||
+		r.ExtendedResourcesTemplate != "" || r.TaintsTemplate != "" || r.ForEachInstance != nil
+}
 
-	ret := RuleOutput{
-		Labels:            labels,
-		Vars:              vars,
-		Annotations:       maps.Clone(r.Annotations),
-		ExtendedResources: maps.Clone(r.ExtendedResources),
-		Taints:            slices.Clone(r.Taints),
+// ruleTemplates returns the templates of the rule, keyed by field name.
+func ruleTemplates(r *nfdv1alpha1.Rule) map[string]string {
+	ret := map[string]string{
+		"LabelsTemplate":            r.LabelsTemplate,
+		"VarsTemplate":              r.VarsTemplate,
+		"AnnotationsTemplate":       r.AnnotationsTemplate,
+		"ExtendedResourcesTemplate": r.ExtendedResourcesTemplate,
+		"TaintsTemplate":            r.TaintsTemplate,
 	}
-	klog.V(2).InfoS("rule matched", "ruleName", r.Name, "ruleOutput", utils.DelayedDumper(ret))
-	return ret, nil
+	maps.Copy(ret, forEachInstanceTemplates(r.ForEachInstance))
+	return ret
 }
 
-func executeLabelsTemplate(r *nfdv1alpha1.Rule, in matchedFeatures, out map[string]string) error {
-	if r.LabelsTemplate == "" {
-		return nil
+// template returns the parsed template of a field, parsing it on the fly if
+// it was not pre-parsed in CompileRule.
+func (c *CompiledRule) template(field, tmpl string) (*templateHelper, error) {
+	if t, ok := c.templates[field]; ok {
+		return t.helper, t.err
 	}
+	return newTemplateHelper(tmpl)
+}
 
-	th, err := newTemplateHelper(r.LabelsTemplate)
+// executeTemplates executes all templates of the rule against the matched
+// features, storing the expanded data in the rule output.
+func (c *CompiledRule) executeTemplates(in matchedFeatures, out *RuleOutput) error {
+	r := c.rule
+	if err := c.executeMapTemplate("LabelsTemplate", r.LabelsTemplate, in, out.Labels); err != nil {
+		return err
+	}
+	if err := c.executeMapTemplate("VarsTemplate", r.VarsTemplate, in, out.Vars); err != nil {
+		return err
+	}
+	if err := c.executeMapTemplate("AnnotationsTemplate", r.AnnotationsTemplate, in, out.Annotations); err != nil {
+		return err
+	}
+	if err := c.executeMapTemplate("ExtendedResourcesTemplate", r.ExtendedResourcesTemplate, in, out.ExtendedResources); err != nil {
+		return err
+	}
+	taints, err := c.executeTaintsTemplate(r.TaintsTemplate, in)
 	if err != nil {
-		return fmt.Errorf("failed to parse LabelsTemplate: %w", err)
+		return err
+	}
+	out.Taints = mergeTaints(out.Taints, taints)
+	return nil
+}
+
+func (c *CompiledRule) executeMapTemplate(field, tmpl string, in matchedFeatures, out map[string]string) error {
+	if tmpl == "" {
+		return nil
 	}
 
-	labels, err := th.expandMap(in)
+	th, err := c.template(field, tmpl)
 	if err != nil {
-		return fmt.Errorf("failed to expand LabelsTemplate: %w", err)
+		return fmt.Errorf("failed to parse %s: %w", field, err)
 	}
-	for k, v := range labels {
-		out[k] = v
+
+	expanded, err := th.expandMap(in)
+	if err != nil {
+		return fmt.Errorf("failed to expand %s: %w", field, err)
 	}
+	maps.Copy(out, expanded)
 	return nil
 }
 
-func executeVarsTemplate(r *nfdv1alpha1.Rule, in matchedFeatures, out map[string]string) error {
-	if r.VarsTemplate == "" {
-		return nil
+func (c *CompiledRule) executeTaintsTemplate(tmpl string, in matchedFeatures) ([]corev1.Taint, error) {
+	if tmpl == "" {
+		return nil, nil
 	}
 
-	th, err := newTemplateHelper(r.VarsTemplate)
+	th, err := c.template("TaintsTemplate", tmpl)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to parse TaintsTemplate: %w", err)
 	}
 
-	vars, err := th.expandMap(in)
+	taints, err := th.expandTaints(in)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to expand TaintsTemplate: %w", err)
 	}
-	for k, v := range vars {
-		out[k] = v
+	return taints, nil
+}
+
+// mergeTaints merges two lists of taints. Taints from the second list take
+// precedence over taints with the same key and effect in the first list.
+func mergeTaints(a, b []corev1.Taint) []corev1.Taint {
+	if len(a) == 0 {
+		return slices.Clone(b)
 	}
-	return nil
+	out := slices.DeleteFunc(slices.Clone(a), func(t corev1.Taint) bool {
+		return slices.ContainsFunc(b, func(o corev1.Taint) bool { return t.MatchTaint(&o) })
+	})
+	return append(out, b...)
 }
 
 type matchedFeatures map[string]domainMatchedFeatures
 
 type domainMatchedFeatures map[string][]MatchedElement
 
-func evaluateMatchAnyElem(e *nfdv1alpha1.MatchAnyElem, features *nfdv1alpha1.Features) (bool, matchedFeatures, error) {
-	return evaluateFeatureMatcher(&e.MatchFeatures, features)
+// addFeatureMatcher compiles all expressions of a FeatureMatcher.
+func (c compiledExpressions) addFeatureMatcher(m *nfdv1alpha1.FeatureMatcher) {
+	for i := range *m {
+		c.addSet((*m)[i].MatchExpressions)
+		c.add((*m)[i].MatchName)
+	}
 }
 
-func evaluateFeatureMatcher(m *nfdv1alpha1.FeatureMatcher, features *nfdv1alpha1.Features) (bool, matchedFeatures, error) {
+func (c compiledExpressions) evaluateMatchAnyElem(e *nfdv1alpha1.MatchAnyElem, features *nfdv1alpha1.Features) (bool, matchedFeatures, error) {
+	return c.evaluateFeatureMatcher(&e.MatchFeatures, features)
+}
+
+func (c compiledExpressions) evaluateFeatureMatcher(m *nfdv1alpha1.FeatureMatcher, features *nfdv1alpha1.Features) (bool, matchedFeatures, error) {
 	matches := make(matchedFeatures, len(*m))
 
 	// Logical AND over the terms
@@ -185,26 +331,26 @@ func evaluateFeatureMatcher(m *nfdv1alpha1.FeatureMatcher, features *nfdv1alpha1
 			}
 			var meTmp []MatchedElement
 			if err == nil && isMatch && term.MatchName != nil {
-				isMatch, meTmp, err = MatchKeyNames(term.MatchName, f.Elements)
+				isMatch, meTmp, err = c.matchKeyNames(term.MatchName, f.Elements)
 				matchedElems = append(matchedElems, meTmp...)
 			}
 		} else if f, ok := features.Attributes[featureName]; ok {
 			if term.MatchExpressions != nil {
-				isMatch, matchedElems, err = MatchGetValues(term.MatchExpressions, f.Elements)
+				isMatch, matchedElems, err = c.matchGetValues(term.MatchExpressions, f.Elements)
 			}
 			var meTmp []MatchedElement
 			if err == nil && isMatch && term.MatchName != nil {
-				isMatch, meTmp, err = MatchValueNames(term.MatchName, f.Elements)
+				isMatch, meTmp, err = c.matchValueNames(term.MatchName, f.Elements)
 				matchedElems = append(matchedElems, meTmp...)
 			}
 		} else if f, ok := features.Instances[featureName]; ok {
 			if term.MatchExpressions != nil {
-				matchedElems, err = MatchGetInstances(term.MatchExpressions, f.Elements)
+				matchedElems, err = c.matchGetInstances(term.MatchExpressions, f.Elements)
 				isMatch = len(matchedElems) > 0
 			}
 			var meTmp []MatchedElement
 			if err == nil && isMatch && term.MatchName != nil {
-				meTmp, err = MatchInstanceAttributeNames(term.MatchName, f.Elements)
+				meTmp, err = c.matchInstanceAttributeNames(term.MatchName, f.Elements)
 				isMatch = len(meTmp) > 0
 				matchedElems = append(matchedElems, meTmp...)
 
@@ -222,47 +368,3 @@ func evaluateFeatureMatcher(m *nfdv1alpha1.FeatureMatcher, features *nfdv1alpha1
 	}
 	return true, matches, nil
 }
-
-type templateHelper struct {
-	template *template.Template
-}
-
-func newTemplateHelper(name string) (*templateHelper, error) {
-	tmpl, err := template.New("").Option("missingkey=error").Parse(name)
-	if err != nil {
-		return nil, fmt.Errorf("invalid template: %w", err)
-	}
-	return &templateHelper{template: tmpl}, nil
-}
-
-func (h *templateHelper) execute(data interface{}) (string, error) {
-	var tmp bytes.Buffer
-	if err := h.template.Execute(&tmp, data); err != nil {
-		return "", err
-	}
-	return tmp.String(), nil
-}
-
-// expandMap is a helper for expanding a template in to a map of strings. Data
-// after executing the template is expexted to be key=value pairs separated by
-// newlines.
-func (h *templateHelper) expandMap(data interface{}) (map[string]string, error) {
-	expanded, err := h.execute(data)
-	if err != nil {
-		return nil, err
-	}
-
-	// Split out individual key-value pairs
-	out := make(map[string]string)
-	for _, item := range strings.Split(expanded, "\n") {
-		// Remove leading/trailing whitespace and skip empty lines
-		if trimmed := strings.TrimSpace(item); trimmed != "" {
-			split := strings.SplitN(trimmed, "=", 2)
-			if len(split) == 1 {
-				return nil, fmt.Errorf("missing value in expanded template line %q, (format must be '<key>=<value>')", trimmed)
-			}
-			out[split[0]] = split[1]
-		}
-	}
-	return out, nil
-}
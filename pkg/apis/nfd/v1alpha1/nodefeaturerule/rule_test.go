@@ -17,9 +17,11 @@ limitations under the License.
 package nodefeaturerule
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
 )
@@ -403,6 +405,63 @@ var-2=
 	_, err = Execute(r2, f)
 	assert.Error(t, err)
 
+	//
+	// Test annotations, extended resources and taints templates
+	//
+	r5 := &nfdv1alpha1.Rule{
+		Annotations:         map[string]string{"annotation-1": "static"},
+		AnnotationsTemplate: `vf-keys={{ pluck "Name" .domain_1.vf_1 | sortAlpha | join "," }}`,
+		ExtendedResources:   map[string]string{"er-1": "1"},
+		ExtendedResourcesTemplate: `
+er-1=will-be-overridden
+{{range .domain_1.if_1}}er-{{index . "attr-1"}}={{index . "attr-1"}}
+{{end}}`,
+		Taints: []corev1.Taint{{Key: "taint-1", Value: "static", Effect: corev1.TaintEffectNoSchedule}},
+		TaintsTemplate: `
+taint-1=will-be-overridden:NoSchedule
+{{range .domain_1.kf_1}}kf-{{.Name}}:NoExecute
+{{end}}`,
+		MatchFeatures: nfdv1alpha1.FeatureMatcher{
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature:   "domain_1.kf_1",
+				MatchName: newMatchExpression(nfdv1alpha1.MatchIn, "key-a"),
+			},
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature:   "domain_1.vf_1",
+				MatchName: newMatchExpression(nfdv1alpha1.MatchIn, "key-1", "key-4"),
+			},
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature: "domain_1.if_1",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"attr-1": newMatchExpression(nfdv1alpha1.MatchLt, "100"),
+				},
+			},
+		},
+	}
+
+	m, err = Execute(r5, f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.Equal(t, map[string]string{"annotation-1": "static", "vf-keys": "key-1,key-4"}, m.Annotations)
+	assert.Equal(t, map[string]string{"er-1": "1", "er-10": "10"}, m.ExtendedResources)
+	assert.Equal(t, []corev1.Taint{
+		{Key: "kf-key-a", Effect: corev1.TaintEffectNoExecute},
+		{Key: "taint-1", Value: "static", Effect: corev1.TaintEffectNoSchedule},
+	}, m.Taints)
+
+	r5.TaintsTemplate = "taint-2=foo"
+	_, err = Execute(r5, f)
+	assert.Error(t, err)
+
+	r5.TaintsTemplate = ""
+	r5.AnnotationsTemplate = "{{"
+	_, err = Execute(r5, f)
+	assert.Error(t, err)
+
+	r5.AnnotationsTemplate = ""
+	r5.ExtendedResourcesTemplate = "er-2"
+	_, err = Execute(r5, f)
+	assert.Error(t, err)
+
 	//
 	// Test matchName
 	//
@@ -442,3 +501,138 @@ var-2=
 	assert.Nilf(t, err, "unexpected error: %v", err)
 	assert.Equal(t, map[string]string(nil), m.Labels, "instances should have matched")
 }
+
+func TestCompiledRule(t *testing.T) {
+	f := nfdv1alpha1.NewFeatures()
+	f.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{"major": "6", "minor": "1"})
+	f.Instances["pci.device"] = nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "8086", "class": "0300"}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "10de", "class": "0302"}),
+	})
+
+	r := newBenchmarkRule()
+	c := CompileRule(r)
+	assert.Same(t, r, c.Rule())
+
+	expected, err := Execute(r, f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.Equal(t, map[string]string{"gpu": "true", "vendor-8086": "true", "vendor-10de": "true"}, expected.Labels)
+
+	// Repeated execution of a compiled rule must produce the same output
+	for i := 0; i < 2; i++ {
+		m, err := c.Execute(f)
+		assert.Nilf(t, err, "unexpected error: %v", err)
+		assert.Equal(t, expected, m)
+	}
+
+	// Non-matching input
+	f.Attributes["kernel.version"].Elements["major"] = "4"
+	m, err := c.Execute(f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.Nil(t, m.Labels)
+
+	// Invalid expressions are reported on execution, the same as with Execute
+	r = &nfdv1alpha1.Rule{
+		Labels: map[string]string{"invalid": "true"},
+		MatchFeatures: nfdv1alpha1.FeatureMatcher{
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature: "kernel.version",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"major": newMatchExpression(nfdv1alpha1.MatchInRegexp, "[invalid"),
+				},
+			},
+		},
+	}
+	_, err = CompileRule(r).Execute(f)
+	assert.Error(t, err, "invalid regexp should have returned an error")
+
+	// Expressions are not validated against non-existent values
+	r.MatchFeatures[0].MatchExpressions = &nfdv1alpha1.MatchExpressionSet{
+		"non-existent": newMatchExpression(nfdv1alpha1.MatchGt, "x"),
+	}
+	m, err = CompileRule(r).Execute(f)
+	assert.Nilf(t, err, "unexpected error: %v", err)
+	assert.Nil(t, m.Labels)
+
+	// Template parse errors are reported on execution
+	r = &nfdv1alpha1.Rule{
+		LabelsTemplate: "{{ .invalid",
+		MatchFeatures: nfdv1alpha1.FeatureMatcher{
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature:          "kernel.version",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"major": newMatchExpression(nfdv1alpha1.MatchExists)},
+			},
+		},
+	}
+	_, err = CompileRule(r).Execute(f)
+	assert.Error(t, err, "invalid template should have returned an error")
+}
+
+// newBenchmarkRule returns a rule using regexps, integer comparison and
+// templates.
+func newBenchmarkRule() *nfdv1alpha1.Rule {
+	return &nfdv1alpha1.Rule{
+		Name:           "benchmark-rule",
+		Labels:         map[string]string{"gpu": "true"},
+		LabelsTemplate: "{{ range .pci.device }}vendor-{{ .vendor }}=true\n{{ end }}",
+		MatchFeatures: nfdv1alpha1.FeatureMatcher{
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature: "kernel.version",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"major": newMatchExpression(nfdv1alpha1.MatchGt, "4"),
+					"minor": newMatchExpression(nfdv1alpha1.MatchGtLt, "-1", "100"),
+				},
+			},
+			nfdv1alpha1.FeatureMatcherTerm{
+				Feature: "pci.device",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"vendor": newMatchExpression(nfdv1alpha1.MatchInRegexp, "^8086$", "^10de$", "^1002$"),
+					"class":  newMatchExpression(nfdv1alpha1.MatchInRegexp, "^03"),
+				},
+			},
+		},
+	}
+}
+
+// newBenchmarkFeatures returns synthetic input features of n nodes.
+func newBenchmarkFeatures(n int) []*nfdv1alpha1.Features {
+	vendors := []string{"8086", "10de", "1002", "15b3"}
+	ret := make([]*nfdv1alpha1.Features, n)
+	for i := range ret {
+		f := nfdv1alpha1.NewFeatures()
+		f.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{
+			"major": fmt.Sprint(4 + i%3),
+			"minor": fmt.Sprint(i % 20),
+		})
+		f.Instances["pci.device"] = nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+			*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": vendors[i%len(vendors)], "class": "0300"}),
+			*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "8086", "class": "0200"}),
+		})
+		ret[i] = f
+	}
+	return ret
+}
+
+func BenchmarkExecute(b *testing.B) {
+	r := newBenchmarkRule()
+	features := newBenchmarkFeatures(2000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, f := range features {
+			_, _ = Execute(r, f)
+		}
+	}
+}
+
+func BenchmarkCompiledRuleExecute(b *testing.B) {
+	c := CompileRule(newBenchmarkRule())
+	features := newBenchmarkFeatures(2000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, f := range features {
+			_, _ = c.Execute(f)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+type templateHelper struct {
+	template *template.Template
+}
+
+func newTemplateHelper(name string) (*templateHelper, error) {
+	tmpl, err := ParseTemplate(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &templateHelper{template: tmpl}, nil
+}
+
+func (h *templateHelper) execute(data interface{}) (string, error) {
+	var tmp bytes.Buffer
+	if err := h.template.Execute(&tmp, data); err != nil {
+		return "", err
+	}
+	return tmp.String(), nil
+}
+
+// expandMap is a helper for expanding a template in to a map of strings. Data
+// after executing the template is expexted to be key=value pairs separated by
+// newlines.
+func (h *templateHelper) expandMap(data interface{}) (map[string]string, error) {
+	expanded, err := h.execute(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split out individual key-value pairs
+	out := make(map[string]string)
+	for _, item := range strings.Split(expanded, "\n") {
+		// Remove leading/trailing whitespace and skip empty lines
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			split := strings.SplitN(trimmed, "=", 2)
+			if len(split) == 1 {
+				return nil, fmt.Errorf("missing value in expanded template line %q, (format must be '<key>=<value>')", trimmed)
+			}
+			out[split[0]] = split[1]
+		}
+	}
+	return out, nil
+}
+
+// expandTaints is a helper for expanding a template in to a list of taints.
+// Data after executing the template is expected to be taints in the format
+// <key>[=<value>]:<effect>, separated by newlines.
+func (h *templateHelper) expandTaints(data interface{}) ([]corev1.Taint, error) {
+	expanded, err := h.execute(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []corev1.Taint
+	for _, item := range strings.Split(expanded, "\n") {
+		// Remove leading/trailing whitespace and skip empty lines
+		trimmed := strings.TrimSpace(item)
+		if trimmed == "" {
+			continue
+		}
+		idx := strings.LastIndex(trimmed, ":")
+		if idx < 0 || idx == len(trimmed)-1 {
+			return nil, fmt.Errorf("missing effect in expanded template line %q, (format must be '<key>[=<value>]:<effect>')", trimmed)
+		}
+		taint := corev1.Taint{Effect: corev1.TaintEffect(trimmed[idx+1:])}
+		split := strings.SplitN(trimmed[:idx], "=", 2)
+		taint.Key = split[0]
+		if len(split) == 2 {
+			taint.Value = split[1]
+		}
+		out = append(out, taint)
+	}
+	return out, nil
+}
+
+// ParseTemplate parses a rule template, making the NFD template function
+// library available.
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Funcs(TemplateFuncs()).Parse(text)
+}
+
+// TemplateFuncs returns the functions available in rule templates, in
+// addition to the built-in functions of text/template. All functions are
+// deterministic, i.e. they do not depend on the environment, time or random
+// numbers.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Strings
+		"lower":        strings.ToLower,
+		"upper":        strings.ToUpper,
+		"trim":         strings.TrimSpace,
+		"trimAll":      func(cutset, s string) string { return strings.Trim(s, cutset) },
+		"trimPrefix":   func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix":   func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":      func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":     func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":    func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":    func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"quote":        strconv.Quote,
+		"toString":     func(v interface{}) string { return fmt.Sprint(v) },
+		"toLabelValue": toLabelValue,
+
+		// Lists
+		"list":      func(v ...interface{}) []interface{} { return v },
+		"split":     func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":      templateJoin,
+		"first":     templateFirst,
+		"last":      templateLast,
+		"has":       templateHas,
+		"uniq":      templateUniq,
+		"sortAlpha": templateSortAlpha,
+		"pluck":     templatePluck,
+
+		// Math
+		"toInt":          toInt64,
+		"add":            func(a, b interface{}) (int64, error) { return intOp(a, b, func(x, y int64) int64 { return x + y }) },
+		"sub":            func(a, b interface{}) (int64, error) { return intOp(a, b, func(x, y int64) int64 { return x - y }) },
+		"mul":            func(a, b interface{}) (int64, error) { return intOp(a, b, func(x, y int64) int64 { return x * y }) },
+		"div":            templateDiv,
+		"mod":            templateMod,
+		"max":            func(a, b interface{}) (int64, error) { return intOp(a, b, func(x, y int64) int64 { return max(x, y) }) },
+		"min":            func(a, b interface{}) (int64, error) { return intOp(a, b, func(x, y int64) int64 { return min(x, y) }) },
+		"formatQuantity": formatQuantity,
+
+		// Regular expressions
+		"regexMatch":      templateRegexMatch,
+		"regexFind":       templateRegexFind,
+		"regexReplaceAll": templateRegexReplaceAll,
+
+		// Versions
+		"semverCompare": semverCompare,
+	}
+}
+
+// toLabelValue sanitizes a string into a valid label value by replacing
+// disallowed characters with underscores, truncating it to the maximum length
+// and stripping non-alphanumeric characters from both ends.
+func toLabelValue(v interface{}) string {
+	s := []byte(fmt.Sprint(v))
+	for i, c := range s {
+		if !isAlphaNum(c) && c != '-' && c != '_' && c != '.' {
+			s[i] = '_'
+		}
+	}
+	if len(s) > k8svalidation.LabelValueMaxLength {
+		s = s[:k8svalidation.LabelValueMaxLength]
+	}
+	return strings.TrimFunc(string(s), func(r rune) bool { return r > 0x7f || !isAlphaNum(byte(r)) })
+}
+
+func isAlphaNum(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// toSlice converts a slice or array of any type into a slice of interfaces.
+func toSlice(list interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(list)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = v.Index(i).Interface()
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("expected a list, got %T", list)
+}
+
+func toStrings(list interface{}) ([]string, error) {
+	l, err := toSlice(list)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(l))
+	for i, v := range l {
+		out[i] = fmt.Sprint(v)
+	}
+	return out, nil
+}
+
+func templateJoin(sep string, list interface{}) (string, error) {
+	l, err := toStrings(list)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(l, sep), nil
+}
+
+func templateFirst(list interface{}) (interface{}, error) {
+	l, err := toSlice(list)
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[0], nil
+}
+
+func templateLast(list interface{}) (interface{}, error) {
+	l, err := toSlice(list)
+	if err != nil || len(l) == 0 {
+		return nil, err
+	}
+	return l[len(l)-1], nil
+}
+
+func templateHas(needle interface{}, list interface{}) (bool, error) {
+	l, err := toSlice(list)
+	if err != nil {
+		return false, err
+	}
+	for _, v := range l {
+		if reflect.DeepEqual(v, needle) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func templateUniq(list interface{}) ([]string, error) {
+	l, err := toStrings(list)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(l))
+	out := make([]string, 0, len(l))
+	for _, v := range l {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func templateSortAlpha(list interface{}) ([]string, error) {
+	l, err := toStrings(list)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(l)
+	return l, nil
+}
+
+// templatePluck returns the values of the named key from a list of maps (e.g.
+// matched instance features). Elements without the key are skipped.
+func templatePluck(key string, list interface{}) ([]string, error) {
+	l, err := toSlice(list)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(l))
+	for _, e := range l {
+		v := reflect.ValueOf(e)
+		if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("expected a list of maps, got element of type %T", e)
+		}
+		if val := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())); val.IsValid() {
+			out = append(out, fmt.Sprint(val.Interface()))
+		}
+	}
+	return out, nil
+}
+
+// toInt64 converts a number or a string holding a number into an int64.
+func toInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(n), 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("not an integer: %q", n)
+		}
+		return i, nil
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint64:
+		return int64(n), nil
+	}
+	return 0, fmt.Errorf("not an integer: %v (%T)", v, v)
+}
+
+func intOp(a, b interface{}, op func(int64, int64) int64) (int64, error) {
+	x, err := toInt64(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toInt64(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y), nil
+}
+
+func templateDiv(a, b interface{}) (int64, error) {
+	if y, err := toInt64(b); err == nil && y == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	return intOp(a, b, func(x, y int64) int64 { return x / y })
+}
+
+func templateMod(a, b interface{}) (int64, error) {
+	if y, err := toInt64(b); err == nil && y == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+	return intOp(a, b, func(x, y int64) int64 { return x % y })
+}
+
+// formatQuantity formats an integer (e.g. a size in bytes) as a Kubernetes
+// quantity with binary SI suffixes, e.g. 17179869184 -> 16Gi.
+func formatQuantity(v interface{}) (string, error) {
+	i, err := toInt64(v)
+	if err != nil {
+		return "", err
+	}
+	return resource.NewQuantity(i, resource.BinarySI).String(), nil
+}
+
+func templateRegexMatch(re, s string) (bool, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return false, err
+	}
+	return r.MatchString(s), nil
+}
+
+func templateRegexFind(re, s string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return r.FindString(s), nil
+}
+
+func templateRegexReplaceAll(re, s, repl string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return r.ReplaceAllString(s, repl), nil
+}
+
+var versionRe = regexp.MustCompile(`^v?([0-9]+(\.[0-9]+)*)`)
+
+// parseVersion parses the leading numeric components of a version string,
+// ignoring any pre-release or build suffix (e.g. "5.15.0-91-generic").
+func parseVersion(s string) ([]int64, error) {
+	m := versionRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	parts := strings.Split(m[1], ".")
+	out := make([]int64, len(parts))
+	for i, p := range parts {
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", s, err)
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// compareVersions compares two versions component-wise, missing components
+// being treated as zero.
+func compareVersions(a, b []int64) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+var semverConstraintRe = regexp.MustCompile(`^(==|=|!=|>=|<=|>|<)?\s*(\S+)$`)
+
+// semverCompare checks a version against a constraint. The constraint is a
+// list of space or comma separated comparisons (e.g. ">=5.4 <6"), all of
+// which must be satisfied. Multiple alternative constraints may be separated
+// with "||".
+func semverCompare(constraint, version string) (bool, error) {
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	for _, alt := range strings.Split(constraint, "||") {
+		satisfied := true
+		terms := strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' })
+		// Join operators separated from their version by whitespace
+		for i := 0; i < len(terms); i++ {
+			if strings.Trim(terms[i], "=!<>") == "" && i+1 < len(terms) {
+				terms[i] += terms[i+1]
+				terms = append(terms[:i+1], terms[i+2:]...)
+			}
+		}
+		if len(terms) == 0 {
+			return false, fmt.Errorf("invalid constraint %q", constraint)
+		}
+		for _, term := range terms {
+			m := semverConstraintRe.FindStringSubmatch(term)
+			if m == nil {
+				return false, fmt.Errorf("invalid constraint %q", constraint)
+			}
+			c, err := parseVersion(m[2])
+			if err != nil {
+				return false, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+			}
+			cmp := compareVersions(v, c)
+			switch m[1] {
+			case "", "=", "==":
+				satisfied = cmp == 0
+			case "!=":
+				satisfied = cmp != 0
+			case ">":
+				satisfied = cmp > 0
+			case ">=":
+				satisfied = cmp >= 0
+			case "<":
+				satisfied = cmp < 0
+			case "<=":
+				satisfied = cmp <= 0
+			}
+			if !satisfied {
+				break
+			}
+		}
+		if satisfied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"dev": []MatchedElement{
+			{"name": "eth0", "vendor": "8086"},
+			{"name": "eth1", "vendor": "15b3"},
+			{"name": "eth2", "vendor": "8086"},
+		},
+		"kernel": "5.15.0-91-generic",
+		"mem":    "17179869184",
+	}
+
+	tcs := []struct {
+		name     string
+		template string
+		result   string
+		err      bool
+	}{
+		{name: "lower", template: `{{ "FoO" | lower }}`, result: "foo"},
+		{name: "upper", template: `{{ "FoO" | upper }}`, result: "FOO"},
+		{name: "trim", template: `{{ "  foo " | trim }}`, result: "foo"},
+		{name: "trimAll", template: `{{ "--foo-" | trimAll "-" }}`, result: "foo"},
+		{name: "trimPrefix", template: `{{ "v1.2" | trimPrefix "v" }}`, result: "1.2"},
+		{name: "trimSuffix", template: `{{ "foo.bar" | trimSuffix ".bar" }}`, result: "foo"},
+		{name: "replace", template: `{{ "a b c" | replace " " "-" }}`, result: "a-b-c"},
+		{name: "contains", template: `{{ .kernel | contains "generic" }}`, result: "true"},
+		{name: "hasPrefix", template: `{{ .kernel | hasPrefix "5." }}`, result: "true"},
+		{name: "hasSuffix", template: `{{ .kernel | hasSuffix "-aws" }}`, result: "false"},
+		{name: "quote", template: `{{ "foo" | quote }}`, result: `"foo"`},
+		{name: "toLabelValue", template: `{{ "-Intel(R) Xeon(R) @ 2.0GHz-" | toLabelValue }}`, result: "Intel_R__Xeon_R____2.0GHz"},
+		{name: "toLabelValue truncate", template: `{{ toLabelValue "` + strings.Repeat("a", 62) + `_bc" }}`, result: strings.Repeat("a", 62)},
+		{name: "split join", template: `{{ split "," "a,b,c" | join "+" }}`, result: "a+b+c"},
+		{name: "list", template: `{{ list 1 "a" 2 | join "," }}`, result: "1,a,2"},
+		{name: "first last", template: `{{ first (split "," "a,b,c") }}{{ last (split "," "a,b,c") }}`, result: "ac"},
+		{name: "has", template: `{{ has "b" (split "," "a,b,c") }}`, result: "true"},
+		{name: "pluck", template: `{{ pluck "name" .dev | join "," }}`, result: "eth0,eth1,eth2"},
+		{name: "uniq sortAlpha", template: `{{ pluck "vendor" .dev | uniq | sortAlpha | join "," }}`, result: "15b3,8086"},
+		{name: "pluck invalid", template: `{{ pluck "name" (list "a") }}`, err: true},
+		{name: "join invalid", template: `{{ join "," "a" }}`, err: true},
+		{name: "add", template: `{{ add "40" 2 }}`, result: "42"},
+		{name: "sub", template: `{{ sub 44 "2" }}`, result: "42"},
+		{name: "mul", template: `{{ mul "0x10" 2 }}`, result: "32"},
+		{name: "div", template: `{{ div .mem 1073741824 }}`, result: "16"},
+		{name: "div by zero", template: `{{ div 1 0 }}`, err: true},
+		{name: "mod", template: `{{ mod 7 3 }}`, result: "1"},
+		{name: "max min", template: `{{ max 1 2 }}{{ min 1 2 }}`, result: "21"},
+		{name: "toInt", template: `{{ printf "%03d" (toInt "7") }}`, result: "007"},
+		{name: "toInt invalid", template: `{{ toInt "foo" }}`, err: true},
+		{name: "formatQuantity", template: `{{ formatQuantity .mem }}`, result: "16Gi"},
+		{name: "regexMatch", template: `{{ regexMatch "^5\\.1[0-9]" .kernel }}`, result: "true"},
+		{name: "regexFind", template: `{{ regexFind "[0-9]+\\.[0-9]+" .kernel }}`, result: "5.15"},
+		{name: "regexReplaceAll", template: `{{ regexReplaceAll "-.*" .kernel "" }}`, result: "5.15.0"},
+		{name: "regex invalid", template: `{{ regexMatch "(" "" }}`, err: true},
+		{name: "semverCompare", template: `{{ semverCompare ">=5.4" .kernel }}`, result: "true"},
+		{name: "semverCompare range", template: `{{ semverCompare ">= 5.4, < 5.15" .kernel }}`, result: "false"},
+		{name: "semverCompare alternatives", template: `{{ semverCompare "<5 || >=5.15.0" .kernel }}`, result: "true"},
+		{name: "semverCompare equal", template: `{{ semverCompare "5.15" .kernel }}`, result: "true"},
+		{name: "semverCompare not equal", template: `{{ semverCompare "!=5.15" .kernel }}`, result: "false"},
+		{name: "semverCompare invalid", template: `{{ semverCompare ">=foo" .kernel }}`, err: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			th, err := newTemplateHelper(tc.template)
+			assert.NoError(t, err)
+
+			res, err := th.execute(data)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.result, res)
+			}
+		})
+	}
+}
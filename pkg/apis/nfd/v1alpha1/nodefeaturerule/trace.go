@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+// Feature types reported in TermTrace.
+const (
+	FlagFeatureType      = "flag"
+	AttributeFeatureType = "attribute"
+	InstanceFeatureType  = "instance"
+)
+
+// RuleTrace is a structured trace of the evaluation of a rule. Unlike the
+// rule evaluation itself, tracing does not short-circuit, i.e. all terms and
+// expressions of the rule are evaluated and reported.
+// +k8s:deepcopy-gen=false
+type RuleTrace struct {
+	// Name of the rule.
+	Name string `json:"name"`
+	// Matched is the final result of the rule evaluation.
+	Matched bool `json:"matched"`
+	// MatchFeatures is the trace of the matchFeatures terms of the rule.
+	MatchFeatures []TermTrace `json:"matchFeatures,omitempty"`
+	// MatchAny is the trace of the matchAny elements of the rule.
+	MatchAny []MatchAnyTrace `json:"matchAny,omitempty"`
+	// Error is the error encountered in the rule execution, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// MatchAnyTrace is a trace of the evaluation of one matchAny element.
+// +k8s:deepcopy-gen=false
+type MatchAnyTrace struct {
+	Matched       bool        `json:"matched"`
+	MatchFeatures []TermTrace `json:"matchFeatures"`
+}
+
+// TermTrace is a trace of the evaluation of one feature matcher term.
+// +k8s:deepcopy-gen=false
+type TermTrace struct {
+	// Feature is the name of the feature the term matches against.
+	Feature string `json:"feature"`
+	// Type is the type of the feature, empty if the feature is not available.
+	Type    string `json:"type,omitempty"`
+	Matched bool   `json:"matched"`
+	// MatchExpressions is the trace of the expressions of flag and attribute
+	// features.
+	MatchExpressions []ExpressionTrace `json:"matchExpressions,omitempty"`
+	// Instances is the trace of the expressions, evaluated against each
+	// instance of instance features.
+	Instances []InstanceTrace `json:"instances,omitempty"`
+	// MatchName is the trace of the matchName expression of the term.
+	MatchName *NameTrace `json:"matchName,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ExpressionTrace is a trace of the evaluation of one expression against one
+// element of a feature.
+// +k8s:deepcopy-gen=false
+type ExpressionTrace struct {
+	// Key is the name of the element the expression is evaluated against.
+	Key   string              `json:"key"`
+	Op    nfdv1alpha1.MatchOp `json:"op"`
+	Value []string            `json:"value,omitempty"`
+	// Exists tells whether the element exists in the input feature.
+	Exists bool `json:"exists"`
+	// Input is the value of the element in the input feature.
+	Input   string `json:"input,omitempty"`
+	Matched bool   `json:"matched"`
+	Error   string `json:"error,omitempty"`
+}
+
+// InstanceTrace is a trace of the evaluation of the expressions of a term
+// against one instance of an instance feature.
+// +k8s:deepcopy-gen=false
+type InstanceTrace struct {
+	// Index of the instance in the input feature.
+	Index            int               `json:"index"`
+	Matched          bool              `json:"matched"`
+	MatchExpressions []ExpressionTrace `json:"matchExpressions,omitempty"`
+	// MatchedNames are the attribute names matched by the matchName
+	// expression of the term.
+	MatchedNames []string `json:"matchedNames,omitempty"`
+}
+
+// NameTrace is a trace of the evaluation of a matchName expression.
+// +k8s:deepcopy-gen=false
+type NameTrace struct {
+	Op      nfdv1alpha1.MatchOp `json:"op"`
+	Value   []string            `json:"value,omitempty"`
+	Matched bool                `json:"matched"`
+	// Names are the matched element names of flag and attribute features.
+	Names []string `json:"names,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+func traceRule(r *nfdv1alpha1.Rule, features *nfdv1alpha1.Features) *RuleTrace {
+	trace := &RuleTrace{Name: r.Name}
+
+	for _, e := range r.MatchAny {
+		t := MatchAnyTrace{Matched: true, MatchFeatures: traceFeatureMatcher(&e.MatchFeatures, features)}
+		for _, tt := range t.MatchFeatures {
+			t.Matched = t.Matched && tt.Matched
+		}
+		trace.MatchAny = append(trace.MatchAny, t)
+	}
+	trace.MatchFeatures = traceFeatureMatcher(&r.MatchFeatures, features)
+
+	return trace
+}
+
+func traceFeatureMatcher(m *nfdv1alpha1.FeatureMatcher, features *nfdv1alpha1.Features) []TermTrace {
+	if len(*m) == 0 {
+		return nil
+	}
+	ret := make([]TermTrace, len(*m))
+	for i := range *m {
+		ret[i] = traceFeatureMatcherTerm(&(*m)[i], features)
+	}
+	return ret
+}
+
+func traceFeatureMatcherTerm(term *nfdv1alpha1.FeatureMatcherTerm, features *nfdv1alpha1.Features) TermTrace {
+	featureName := strings.ToLower(term.Feature)
+	trace := TermTrace{Feature: term.Feature}
+
+	if f, ok := features.Flags[featureName]; ok {
+		trace.Type = FlagFeatureType
+		if term.MatchExpressions != nil {
+			trace.MatchExpressions, _ = traceExpressions(term.MatchExpressions, func(e *nfdv1alpha1.MatchExpression, key string) ExpressionTrace {
+				_, exists := f.Elements[key]
+				t := ExpressionTrace{Exists: exists}
+				t.Matched, t.Error = errString(evaluateMatchExpressionKeys(e, key, f.Elements))
+				return t
+			})
+		}
+		if term.MatchName != nil {
+			trace.MatchName = newNameTrace(term.MatchName)
+			_, names, err := MatchKeyNames(term.MatchName, f.Elements)
+			trace.MatchName.setNames(names, err)
+		}
+	} else if f, ok := features.Attributes[featureName]; ok {
+		trace.Type = AttributeFeatureType
+		if term.MatchExpressions != nil {
+			trace.MatchExpressions, _ = traceExpressions(term.MatchExpressions, valueExpressionTracer(f.Elements))
+		}
+		if term.MatchName != nil {
+			trace.MatchName = newNameTrace(term.MatchName)
+			_, names, err := MatchValueNames(term.MatchName, f.Elements)
+			trace.MatchName.setNames(names, err)
+		}
+	} else if f, ok := features.Instances[featureName]; ok {
+		trace.Type = InstanceFeatureType
+		if term.MatchName != nil {
+			trace.MatchName = newNameTrace(term.MatchName)
+		}
+		for i, inst := range f.Elements {
+			it := InstanceTrace{Index: i, Matched: true}
+			if term.MatchExpressions != nil {
+				it.MatchExpressions, it.Matched = traceExpressions(term.MatchExpressions, valueExpressionTracer(inst.Attributes))
+			}
+			if term.MatchName != nil {
+				_, names, err := MatchValueNames(term.MatchName, inst.Attributes)
+				if err != nil {
+					trace.MatchName.Error = err.Error()
+				}
+				for _, n := range names {
+					it.MatchedNames = append(it.MatchedNames, n["Name"])
+				}
+				trace.MatchName.Matched = trace.MatchName.Matched || len(names) > 0
+			}
+			trace.Instances = append(trace.Instances, it)
+		}
+	} else {
+		trace.Error = fmt.Sprintf("feature %q not available", featureName)
+		return trace
+	}
+
+	// The result of the term comes from the same evaluation as in Execute,
+	// the traces of the individual elements above are informational only
+	matched, _, err := compiledExpressions(nil).evaluateFeatureMatcher(&nfdv1alpha1.FeatureMatcher{*term}, features)
+	trace.Matched, trace.Error = errString(matched, err)
+	return trace
+}
+
+// traceExpressions evaluates all expressions of an expression set, in
+// alphabetical order of the keys, using the given evaluation function.
+func traceExpressions(m *nfdv1alpha1.MatchExpressionSet, eval func(*nfdv1alpha1.MatchExpression, string) ExpressionTrace) ([]ExpressionTrace, bool) {
+	keys := make([]string, 0, len(*m))
+	for k := range *m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	matched := true
+	ret := make([]ExpressionTrace, len(keys))
+	for i, k := range keys {
+		e := (*m)[k]
+		t := eval(e, k)
+		t.Key = k
+		t.Op = e.Op
+		t.Value = e.Value
+		matched = matched && t.Matched
+		ret[i] = t
+	}
+	return ret, matched
+}
+
+func valueExpressionTracer(values map[string]string) func(*nfdv1alpha1.MatchExpression, string) ExpressionTrace {
+	return func(e *nfdv1alpha1.MatchExpression, key string) ExpressionTrace {
+		v, exists := values[key]
+		t := ExpressionTrace{Exists: exists, Input: v}
+		t.Matched, t.Error = errString(evaluateMatchExpression(e, exists, v))
+		return t
+	}
+}
+
+func newNameTrace(m *nfdv1alpha1.MatchExpression) *NameTrace {
+	return &NameTrace{Op: m.Op, Value: m.Value}
+}
+
+func (t *NameTrace) setNames(names []MatchedElement, err error) {
+	if err != nil {
+		t.Error = err.Error()
+		return
+	}
+	for _, n := range names {
+		t.Names = append(t.Names, n["Name"])
+	}
+	t.Matched = len(names) > 0
+}
+
+func errString(matched bool, err error) (bool, string) {
+	if err != nil {
+		return false, err.Error()
+	}
+	return matched, ""
+}
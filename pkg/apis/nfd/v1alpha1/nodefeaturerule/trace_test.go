@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodefeaturerule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+)
+
+func TestExecuteWithTrace(t *testing.T) {
+	f := nfdv1alpha1.NewFeatures()
+	f.Flags["kernel.loadedmodule"] = nfdv1alpha1.NewFlagFeatures("mod-1", "mod-2")
+	f.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{"major": "6", "minor": "1"})
+	f.Instances["pci.device"] = nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "8086", "class": "0200"}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": "10de", "class": "0300"}),
+	})
+
+	r := &nfdv1alpha1.Rule{
+		Name:   "test-rule",
+		Labels: map[string]string{"foo": "bar"},
+		MatchFeatures: nfdv1alpha1.FeatureMatcher{
+			{
+				Feature: "kernel.loadedmodule",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"mod-1": newMatchExpression(nfdv1alpha1.MatchExists),
+					"mod-3": newMatchExpression(nfdv1alpha1.MatchDoesNotExist),
+				},
+			},
+			{
+				Feature: "kernel.version",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"major": newMatchExpression(nfdv1alpha1.MatchGt, "5"),
+				},
+				MatchName: newMatchExpression(nfdv1alpha1.MatchIn, "minor", "patch"),
+			},
+			{
+				Feature: "pci.device",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+					"vendor": newMatchExpression(nfdv1alpha1.MatchIn, "10de"),
+				},
+			},
+		},
+		MatchAny: []nfdv1alpha1.MatchAnyElem{
+			{MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+				Feature:          "kernel.version",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"major": newMatchExpression(nfdv1alpha1.MatchLt, "5")},
+			}}},
+			{MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+				Feature:          "kernel.version",
+				MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"major": newMatchExpression(nfdv1alpha1.MatchIsTrue)},
+			}}},
+		},
+	}
+
+	// Rule does not match because none of the matchAny elements match
+	out, trace, err := ExecuteWithTrace(r, f)
+	assert.NoError(t, err)
+	assert.Nil(t, out.Labels)
+
+	expected := &RuleTrace{
+		Name:    "test-rule",
+		Matched: false,
+		MatchFeatures: []TermTrace{
+			{
+				Feature: "kernel.loadedmodule",
+				Type:    FlagFeatureType,
+				Matched: true,
+				MatchExpressions: []ExpressionTrace{
+					{Key: "mod-1", Op: nfdv1alpha1.MatchExists, Exists: true, Matched: true},
+					{Key: "mod-3", Op: nfdv1alpha1.MatchDoesNotExist, Exists: false, Matched: true},
+				},
+			},
+			{
+				Feature: "kernel.version",
+				Type:    AttributeFeatureType,
+				Matched: true,
+				MatchExpressions: []ExpressionTrace{
+					{Key: "major", Op: nfdv1alpha1.MatchGt, Value: []string{"5"}, Exists: true, Input: "6", Matched: true},
+				},
+				MatchName: &NameTrace{Op: nfdv1alpha1.MatchIn, Value: []string{"minor", "patch"}, Matched: true, Names: []string{"minor"}},
+			},
+			{
+				Feature: "pci.device",
+				Type:    InstanceFeatureType,
+				Matched: true,
+				Instances: []InstanceTrace{
+					{Index: 0, Matched: false, MatchExpressions: []ExpressionTrace{
+						{Key: "vendor", Op: nfdv1alpha1.MatchIn, Value: []string{"10de"}, Exists: true, Input: "8086", Matched: false},
+					}},
+					{Index: 1, Matched: true, MatchExpressions: []ExpressionTrace{
+						{Key: "vendor", Op: nfdv1alpha1.MatchIn, Value: []string{"10de"}, Exists: true, Input: "10de", Matched: true},
+					}},
+				},
+			},
+		},
+		MatchAny: []MatchAnyTrace{
+			{Matched: false, MatchFeatures: []TermTrace{{
+				Feature: "kernel.version",
+				Type:    AttributeFeatureType,
+				MatchExpressions: []ExpressionTrace{
+					{Key: "major", Op: nfdv1alpha1.MatchLt, Value: []string{"5"}, Exists: true, Input: "6", Matched: false},
+				},
+			}}},
+			{Matched: false, MatchFeatures: []TermTrace{{
+				Feature: "kernel.version",
+				Type:    AttributeFeatureType,
+				MatchExpressions: []ExpressionTrace{
+					{Key: "major", Op: nfdv1alpha1.MatchIsTrue, Exists: true, Input: "6", Matched: false},
+				},
+			}}},
+		},
+	}
+	assert.Equal(t, expected, trace)
+
+	// Rule matches after making one matchAny element match
+	r.MatchAny[0].MatchFeatures[0].MatchExpressions = &nfdv1alpha1.MatchExpressionSet{"major": newMatchExpression(nfdv1alpha1.MatchGt, "5")}
+	out, trace, err = ExecuteWithTrace(r, f)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "bar"}, out.Labels)
+	assert.True(t, trace.Matched)
+	assert.True(t, trace.MatchAny[0].Matched)
+	assert.False(t, trace.MatchAny[1].Matched)
+
+	// Errors are reported in the trace
+	r.MatchFeatures = append(r.MatchFeatures, nfdv1alpha1.FeatureMatcherTerm{
+		Feature:          "kernel.version",
+		MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"minor": newMatchExpression(nfdv1alpha1.MatchGt, "a")},
+	}, nfdv1alpha1.FeatureMatcherTerm{
+		Feature:          "foo.bar",
+		MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"foo": newMatchExpression(nfdv1alpha1.MatchExists)},
+	})
+	_, trace, err = ExecuteWithTrace(r, f)
+	assert.Error(t, err)
+	assert.False(t, trace.Matched)
+	assert.Equal(t, err.Error(), trace.Error)
+	assert.NotEmpty(t, trace.MatchFeatures[3].MatchExpressions[0].Error)
+	assert.False(t, trace.MatchFeatures[3].Matched)
+	assert.Equal(t, "feature \"foo.bar\" not available", trace.MatchFeatures[4].Error)
+
+	// Term results agree with the rule execution even if only some of the
+	// instances match
+	r.MatchAny = nil
+	r.MatchFeatures = nfdv1alpha1.FeatureMatcher{{
+		Feature:          "pci.device",
+		MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"vendor": newMatchExpression(nfdv1alpha1.MatchGt, "5")},
+	}}
+	_, trace, err = ExecuteWithTrace(r, f)
+	assert.Error(t, err)
+	assert.True(t, trace.MatchFeatures[0].Instances[0].Matched)
+	assert.False(t, trace.MatchFeatures[0].Matched)
+	assert.Equal(t, err.Error(), trace.MatchFeatures[0].Error)
+}
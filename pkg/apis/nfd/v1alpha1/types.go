@@ -115,6 +115,7 @@ type NodeFeatureRuleList struct {
 // customization of node objects, such as node labeling.
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster,shortName=nfr
+// +kubebuilder:validation:XValidation:rule="self.metadata.name != 'matched'",message="the name matched is reserved for the rule.matched feature"
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +genclient
 // +genclient:nonNamespaced
@@ -127,6 +128,12 @@ type NodeFeatureRule struct {
 
 // NodeFeatureRuleSpec describes a NodeFeatureRule.
 type NodeFeatureRuleSpec struct {
+	// NodeSelector restricts the rules to nodes whose labels match the
+	// selector. The rules are not evaluated at all for other nodes. An empty
+	// selector matches all nodes.
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
 	// Rules is a list of node customization rules.
 	Rules []Rule `json:"rules"`
 }
@@ -150,6 +157,12 @@ type Rule struct {
 	// +optional
 	Annotations map[string]string `json:"annotations"`
 
+	// AnnotationsTemplate specifies a template to expand for dynamically
+	// generating multiple annotations. Data (after template expansion) must be
+	// keys with a value (<key>=<value>) separated by newlines.
+	// +optional
+	AnnotationsTemplate string `json:"annotationsTemplate,omitempty"`
+
 	// Vars is the variables to store if the rule matches. Variables do not
 	// directly inflict any changes in the node object. However, they can be
 	// referenced from other rules enabling more complex rule hierarchies,
@@ -167,10 +180,28 @@ type Rule struct {
 	// +optional
 	Taints []corev1.Taint `json:"taints,omitempty"`
 
+	// TaintsTemplate specifies a template to expand for dynamically generating
+	// multiple taints. Data (after template expansion) must be taints in the
+	// format <key>[=<value>]:<effect> separated by newlines.
+	// +optional
+	TaintsTemplate string `json:"taintsTemplate,omitempty"`
+
 	// ExtendedResources to create if the rule matches.
 	// +optional
 	ExtendedResources map[string]string `json:"extendedResources"`
 
+	// ExtendedResourcesTemplate specifies a template to expand for dynamically
+	// generating multiple extended resources. Data (after template expansion)
+	// must be resource names with a value (<name>=<value>) separated by
+	// newlines.
+	// +optional
+	ExtendedResourcesTemplate string `json:"extendedResourcesTemplate,omitempty"`
+
+	// ForEachInstance specifies output to create separately for each matched
+	// instance of an instance feature.
+	// +optional
+	ForEachInstance *ForEachInstance `json:"forEachInstance,omitempty"`
+
 	// MatchFeatures specifies a set of matcher terms all of which must match.
 	// +optional
 	MatchFeatures FeatureMatcher `json:"matchFeatures"`
@@ -178,6 +209,48 @@ type Rule struct {
 	// MatchAny specifies a list of matchers one of which must match.
 	// +optional
 	MatchAny []MatchAnyElem `json:"matchAny"`
+
+	// After specifies rules that must be evaluated before this rule, in the
+	// format <nodefeaturerule-name>/<rule-name>. A reference without a rule
+	// name refers to all rules of the NodeFeatureRule object. References to
+	// rules of the same NodeFeatureRule object must refer to preceding rules.
+	// +optional
+	After []string `json:"after,omitempty"`
+
+	// Priority of the rule output. If multiple rules produce the same label,
+	// annotation, extended resource or taint with different values, the value
+	// from the rule with the highest priority is used. Between rules of equal
+	// priority the rule evaluated last wins. Defaults to 0.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+}
+
+// ForEachInstance specifies output that is created for each instance of an
+// instance feature matched by the rule. The keys and values of the output are
+// templates that are expanded with the attributes of the instance, e.g.
+// "gpu-{{ .vendor }}-{{ .device }}". Expanded keys are sanitized into valid
+// names. Extended resources produced by multiple instances with the same name
+// are summed, e.g. for counting the instances. Labels and annotations produced
+// by multiple instances with the same key but different values get a numeric
+// suffix added to the key, e.g. "gpu-a100-2".
+type ForEachInstance struct {
+	// Feature is the instance feature to iterate over, in the format
+	// <domain>.<feature>. The feature must be matched by matchFeatures or
+	// matchAny of the rule.
+	Feature string `json:"feature"`
+
+	// Labels to create for each matched instance. Label values are sanitized
+	// into valid label values.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to create for each matched instance.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ExtendedResources to create for each matched instance.
+	// +optional
+	ExtendedResources map[string]string `json:"extendedResources,omitempty"`
 }
 
 // MatchAnyElem specifies one sub-matcher of MatchAny.
@@ -217,6 +290,14 @@ type MatchExpression struct {
 	// Op is the operator to be applied.
 	Op MatchOp `json:"op"`
 
+	// Type is the type of the values that the Gt, Lt and GtLt operators
+	// compare. Int (the default) compares integer numbers, Float decimal
+	// numbers and Quantity numbers in the Kubernetes resource quantity format
+	// with an optional unit, e.g. 16Gi, 3.2GHz or 100000Mb/s. Type must be
+	// empty for other operators.
+	// +optional
+	Type MatchValueType `json:"type,omitempty"`
+
 	// Value is the list of values that the operand evaluates the input
 	// against. Value should be empty if the operator is Exists, DoesNotExist,
 	// IsTrue or IsFalse. Value should contain exactly one element if the
@@ -234,6 +315,24 @@ type MatchOp string
 // MatchValue is the list of values associated with a MatchExpression.
 type MatchValue []string
 
+// MatchValueType is the type of the values compared by the numeric operators
+// of a MatchExpression.
+// +kubebuilder:validation:Enum="Int";"Float";"Quantity"
+type MatchValueType string
+
+const (
+	// MatchValueTypeInt compares integer numbers. This is the default if the
+	// type is empty.
+	MatchValueTypeInt MatchValueType = "Int"
+	// MatchValueTypeFloat compares decimal numbers, e.g. 2.5 or 1e-3.
+	MatchValueTypeFloat MatchValueType = "Float"
+	// MatchValueTypeQuantity compares numbers in the Kubernetes resource
+	// quantity format, e.g. 16Gi or 2.5G. A trailing unit after the
+	// quantity is ignored, e.g. 3.2GHz is compared as 3.2G and 100000Mb/s as
+	// 100000M.
+	MatchValueTypeQuantity MatchValueType = "Quantity"
+)
+
 const (
 	// MatchAny returns always true.
 	MatchAny MatchOp = ""
@@ -254,18 +353,19 @@ const (
 	MatchDoesNotExist MatchOp = "DoesNotExist"
 	// MatchGt returns true if the input is greater than the value of the
 	// expression (number of values in the expression must be exactly one).
-	// Both the input and value must be integer numbers, otherwise an error is
-	// returned.
+	// Both the input and value must be numbers of the type of the expression,
+	// otherwise an error is returned.
 	MatchGt MatchOp = "Gt"
 	// MatchLt returns true if the input is less  than the value of the
 	// expression (number of values in the expression must be exactly one).
-	// Both the input and value must be integer numbers, otherwise an error is
-	// returned.
+	// Both the input and value must be numbers of the type of the expression,
+	// otherwise an error is returned.
 	MatchLt MatchOp = "Lt"
 	// MatchGtLt returns true if the input is between two values, i.e. greater
 	// than the first value and less than the second value of the expression
 	// (number of values in the expression must be exactly two). Both the input
-	// and values must be integer numbers, otherwise an error is returned.
+	// and values must be numbers of the type of the expression, otherwise an
+	// error is returned.
 	MatchGtLt MatchOp = "GtLt"
 	// MatchIsTrue returns true if the input holds the value "true". The
 	// expression must not have any values.
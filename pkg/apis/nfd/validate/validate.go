@@ -18,14 +18,15 @@ package validate
 
 import (
 	"fmt"
+	"slices"
 	"strings"
-	"text/template"
 
 	corev1 "k8s.io/api/core/v1"
 	k8sQuantity "k8s.io/apimachinery/pkg/api/resource"
 	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
 )
 
 var (
@@ -67,18 +68,94 @@ func MatchFeatures(matchFeature nfdv1alpha1.FeatureMatcher) []error {
 }
 
 // Template validates a template string and returns a slice of errors if the
-// template is invalid.
+// template is invalid. The template functions available in rule templates are
+// taken into account.
 func Template(labelsTemplate string) []error {
 	var validationErr []error
 
 	// Validate template
-	_, err := template.New("").Option("missingkey=error").Parse(labelsTemplate)
+	_, err := nodefeaturerule.ParseTemplate(labelsTemplate)
 	if err != nil {
 		validationErr = append(validationErr, fmt.Errorf("invalid template: %w", err))
 	}
 	return validationErr
 }
 
+// ForEachInstance validates the forEachInstance output of a rule and returns
+// a slice of errors if it is invalid. The feature to iterate over must be
+// matched by a term of the rule and all keys and values must be valid
+// templates.
+func ForEachInstance(rule *nfdv1alpha1.Rule) []error {
+	var validationErr []error
+
+	fe := rule.ForEachInstance
+	if fe == nil {
+		return nil
+	}
+
+	if len(strings.SplitN(fe.Feature, ".", 2)) != 2 {
+		validationErr = append(validationErr, fmt.Errorf("invalid feature name %q in forEachInstance of rule %q (not <domain>.<feature>)", fe.Feature, rule.Name))
+	} else {
+		isMatched := func(m nfdv1alpha1.FeatureMatcher) bool {
+			return slices.ContainsFunc(m, func(term nfdv1alpha1.FeatureMatcherTerm) bool { return term.Feature == fe.Feature })
+		}
+		matched := isMatched(rule.MatchFeatures)
+		for _, e := range rule.MatchAny {
+			matched = matched || isMatched(e.MatchFeatures)
+		}
+		if !matched {
+			validationErr = append(validationErr, fmt.Errorf("feature %q of forEachInstance is not matched by rule %q", fe.Feature, rule.Name))
+		}
+	}
+
+	for _, m := range []map[string]string{fe.Labels, fe.Annotations, fe.ExtendedResources} {
+		for k, v := range m {
+			validationErr = append(validationErr, Template(k)...)
+			validationErr = append(validationErr, Template(v)...)
+		}
+	}
+
+	return validationErr
+}
+
+// After validates the rule references in the after field of the rules of a
+// NodeFeatureRule object and returns a slice of errors if any of the
+// references are invalid. References to rules of the same object must refer to
+// preceding rules. The name of the object must not collide with the
+// rule.matched backreference feature.
+func After(nfr *nfdv1alpha1.NodeFeatureRule) []error {
+	var validationErr []error
+
+	if nfr.Name == nfdv1alpha1.RuleBackrefFeature {
+		validationErr = append(validationErr, fmt.Errorf("invalid NodeFeatureRule name %q, the name is reserved for the %s.%s feature", nfr.Name, nfdv1alpha1.RuleBackrefDomain, nfdv1alpha1.RuleBackrefFeature))
+	}
+
+	for i, rule := range nfr.Spec.Rules {
+		for _, ref := range rule.After {
+			nfrName, ruleName, err := nodefeaturerule.ParseRuleReference(ref)
+			if err != nil {
+				validationErr = append(validationErr, err)
+				continue
+			}
+			if nfrName != nfr.Name {
+				continue
+			}
+			if ruleName == "" {
+				validationErr = append(validationErr, fmt.Errorf("invalid rule reference %q in rule %q, a rule cannot be evaluated after its own NodeFeatureRule object", ref, rule.Name))
+				continue
+			}
+			idx := slices.IndexFunc(nfr.Spec.Rules, func(r nfdv1alpha1.Rule) bool { return r.Name == ruleName })
+			if idx < 0 {
+				validationErr = append(validationErr, fmt.Errorf("invalid rule reference %q in rule %q, rule %q not found", ref, rule.Name, ruleName))
+			} else if idx >= i {
+				validationErr = append(validationErr, fmt.Errorf("invalid rule reference %q in rule %q, references to the same NodeFeatureRule object must refer to preceding rules", ref, rule.Name))
+			}
+		}
+	}
+
+	return validationErr
+}
+
 // Labels validates a map of labels and returns a slice of errors if any of the
 // labels are invalid.
 func Labels(labels map[string]string) []error {
@@ -5,6 +5,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
 )
 
 func TestAnnotation(t *testing.T) {
@@ -214,3 +217,179 @@ func TestExtendedResource(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		fails    bool
+	}{
+		{
+			name:     "Valid template",
+			template: "{{ range .pci.device }}vendor-{{ .vendor }}=true\n{{ end }}",
+		},
+		{
+			name:     "Valid template using template functions",
+			template: `{{ range .cpu.model }}model={{ .Value | lower | toLabelValue }}{{ end }}`,
+		},
+		{
+			name:     "Invalid template syntax",
+			template: "{{",
+			fails:    true,
+		},
+		{
+			name:     "Unknown function",
+			template: `{{ "foo" | nonExistentFunc }}`,
+			fails:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Template(tt.template)
+			if tt.fails {
+				assert.NotEmpty(t, errs)
+			} else {
+				assert.Empty(t, errs)
+			}
+		})
+	}
+}
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		nfrName string
+		after   []string
+		want    string
+	}{
+		{
+			name:    "Reserved NodeFeatureRule name",
+			nfrName: "matched",
+			want:    "the name is reserved",
+		},
+		{
+			name:  "Valid references",
+			after: []string{"other-nfr", "other-nfr/rule-1", "test-nfr/rule-1"},
+		},
+		{
+			name:  "Empty NodeFeatureRule name",
+			after: []string{"/rule-1"},
+			want:  "NodeFeatureRule name must not be empty",
+		},
+		{
+			name:  "Empty rule name",
+			after: []string{"other-nfr/"},
+			want:  "rule name must not be empty",
+		},
+		{
+			name:  "Reference to own NodeFeatureRule object",
+			after: []string{"test-nfr"},
+			want:  "cannot be evaluated after its own NodeFeatureRule object",
+		},
+		{
+			name:  "Reference to self",
+			after: []string{"test-nfr/rule-2"},
+			want:  "must refer to preceding rules",
+		},
+		{
+			name:  "Reference to a subsequent rule",
+			after: []string{"test-nfr/rule-3"},
+			want:  "must refer to preceding rules",
+		},
+		{
+			name:  "Reference to a non-existent rule",
+			after: []string{"test-nfr/rule-4"},
+			want:  "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nfrName := tt.nfrName
+			if nfrName == "" {
+				nfrName = "test-nfr"
+			}
+			nfr := &nfdv1alpha1.NodeFeatureRule{
+				ObjectMeta: metav1.ObjectMeta{Name: nfrName},
+				Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+					Rules: []nfdv1alpha1.Rule{{Name: "rule-1"}, {Name: "rule-2", After: tt.after}, {Name: "rule-3"}},
+				},
+			}
+			errs := After(nfr)
+			if tt.want == "" {
+				assert.Empty(t, errs)
+			} else {
+				assert.Len(t, errs, 1)
+				assert.ErrorContains(t, errs[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestForEachInstance(t *testing.T) {
+	tests := []struct {
+		name string
+		fe   *nfdv1alpha1.ForEachInstance
+		want string
+	}{
+		{
+			name: "No forEachInstance",
+		},
+		{
+			name: "Valid forEachInstance",
+			fe: &nfdv1alpha1.ForEachInstance{
+				Feature: "pci.device",
+				Labels:  map[string]string{"gpu-{{ .vendor }}": "{{ .device | toLabelValue }}"},
+			},
+		},
+		{
+			name: "Feature matched in matchAny",
+			fe:   &nfdv1alpha1.ForEachInstance{Feature: "usb.device"},
+		},
+		{
+			name: "Invalid feature name",
+			fe:   &nfdv1alpha1.ForEachInstance{Feature: "pci"},
+			want: "not <domain>.<feature>",
+		},
+		{
+			name: "Feature not matched",
+			fe:   &nfdv1alpha1.ForEachInstance{Feature: "network.device"},
+			want: "is not matched by rule",
+		},
+		{
+			name: "Invalid key template",
+			fe: &nfdv1alpha1.ForEachInstance{
+				Feature:     "pci.device",
+				Annotations: map[string]string{"gpu-{{ .vendor": "true"},
+			},
+			want: "invalid template",
+		},
+		{
+			name: "Invalid value template",
+			fe: &nfdv1alpha1.ForEachInstance{
+				Feature:           "pci.device",
+				ExtendedResources: map[string]string{"gpu": "{{ nonExistentFunc }}"},
+			},
+			want: "invalid template",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &nfdv1alpha1.Rule{
+				Name:            "rule-1",
+				ForEachInstance: tt.fe,
+				MatchFeatures:   nfdv1alpha1.FeatureMatcher{{Feature: "pci.device"}},
+				MatchAny:        []nfdv1alpha1.MatchAnyElem{{MatchFeatures: nfdv1alpha1.FeatureMatcher{{Feature: "usb.device"}}}},
+			}
+			errs := ForEachInstance(rule)
+			if tt.want == "" {
+				assert.Empty(t, errs)
+			} else {
+				assert.Len(t, errs, 1)
+				assert.ErrorContains(t, errs[0], tt.want)
+			}
+		})
+	}
+}
@@ -23,24 +23,16 @@ import (
 
 	"sigs.k8s.io/yaml"
 
-	corev1 "k8s.io/api/core/v1"
-
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
 	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
 	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/validate"
 )
 
-func DryRun(nodefeaturerulepath, nodefeaturepath string) []error {
+func DryRun(nodefeaturerulepath, nodefeaturepath string, explain bool) []error {
 	var errs []error
-	nfr := nfdv1alpha1.NodeFeatureRule{}
 	nf := nfdv1alpha1.NodeFeature{}
 
-	nfrFile, err := os.ReadFile(nodefeaturerulepath)
-	if err != nil {
-		return []error{fmt.Errorf("error reading NodeFeatureRule file: %w", err)}
-	}
-
-	err = yaml.Unmarshal(nfrFile, &nfr)
+	nfrs, err := readNodeFeatureRules(nodefeaturerulepath)
 	if err != nil {
 		return []error{fmt.Errorf("error parsing NodeFeatureRule: %w", err)}
 	}
@@ -55,28 +47,69 @@ func DryRun(nodefeaturerulepath, nodefeaturepath string) []error {
 		return []error{fmt.Errorf("error parsing NodeFeatureRule: %w", err)}
 	}
 
-	errs = append(errs, processNodeFeatureRule(nfr, nf.Spec)...)
+	errs = append(errs, processNodeFeatureRules(nfrs, nf.Spec, explain)...)
 
 	return errs
 }
 
-func processNodeFeatureRule(nodeFeatureRule nfdv1alpha1.NodeFeatureRule, nodeFeature nfdv1alpha1.NodeFeatureSpec) []error {
+// processNodeFeatureRules evaluates the NodeFeatureRule objects against the
+// node features, in the same order as nfd-master does.
+func processNodeFeatureRules(nodeFeatureRules []*nfdv1alpha1.NodeFeatureRule, nodeFeature nfdv1alpha1.NodeFeatureSpec, explain bool) []error {
 	var errs []error
-	var taints []corev1.Taint
 
-	extendedResources := make(map[string]string)
-	labels := make(map[string]string)
-	annotations := make(map[string]string)
+	nodeFeatureRules, err := nodefeaturerule.SortNodeFeatureRules(nodeFeatureRules)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	output := nodefeaturerule.NewOutputMerger()
+	for _, nfr := range nodeFeatureRules {
+		if len(nodeFeatureRules) > 1 {
+			fmt.Println("Processing NodeFeatureRule: ", nfr.Name)
+		}
+		errs = append(errs, processNodeFeatureRule(nfr, &nodeFeature, output, explain)...)
+	}
+
+	if len(output.Conflicts) > 0 {
+		fmt.Println("***\tConflicts\t***")
+		for _, c := range output.Conflicts {
+			fmt.Println(c)
+		}
+	}
+	return errs
+}
+
+// processNodeFeatureRule evaluates the rules of one NodeFeatureRule object,
+// printing the output of the object. The output is also merged into the node
+// output for detecting conflicts with other objects.
+func processNodeFeatureRule(nodeFeatureRule *nfdv1alpha1.NodeFeatureRule, nodeFeature *nfdv1alpha1.NodeFeatureSpec, output *nodefeaturerule.OutputMerger, explain bool) []error {
+	var errs []error
+
+	// Output of the object, with conflicts between its rules resolved
+	objOut := nodefeaturerule.NewOutputMerger()
 
 	for _, rule := range nodeFeatureRule.Spec.Rules {
 		fmt.Println("Processing rule: ", rule.Name)
-		ruleOut, err := nodefeaturerule.Execute(&rule, &nodeFeature.Features)
+		var ruleOut nodefeaturerule.RuleOutput
+		var err error
+		if explain {
+			var trace *nodefeaturerule.RuleTrace
+			ruleOut, trace, err = nodefeaturerule.ExecuteWithTrace(&rule, &nodeFeature.Features)
+			printTrace(os.Stdout, trace)
+		} else {
+			ruleOut, err = nodefeaturerule.Execute(&rule, &nodeFeature.Features)
+		}
 		if err != nil {
 			errs = append(errs, fmt.Errorf("failed to process rule: %q - %w", rule.Name, err))
 			continue
 		}
-		// taints
-		taints = append(taints, ruleOut.Taints...)
+		// Node output of the rule, with dynamic values resolved
+		nodeOut := nodefeaturerule.RuleOutput{
+			Labels:            make(map[string]string),
+			ExtendedResources: make(map[string]string),
+			Annotations:       ruleOut.Annotations,
+			Taints:            ruleOut.Taints,
+		}
 		// labels
 		for k, v := range ruleOut.Labels {
 			// Dynamic Value
@@ -86,10 +119,9 @@ func processNodeFeatureRule(nodeFeatureRule nfdv1alpha1.NodeFeatureRule, nodeFea
 					errs = append(errs, fmt.Errorf("failed to get dynamic value for label %q: %w", k, err))
 					continue
 				}
-				labels[k] = dvalue
-				continue
+				v = dvalue
 			}
-			labels[k] = v
+			nodeOut.Labels[k] = v
 		}
 		// extended resources
 		for k, v := range ruleOut.ExtendedResources {
@@ -100,16 +132,24 @@ func processNodeFeatureRule(nodeFeatureRule nfdv1alpha1.NodeFeatureRule, nodeFea
 					errs = append(errs, fmt.Errorf("failed to get dynamic value for extendedResource %q: %w", k, err))
 					continue
 				}
-				extendedResources[k] = dvalue
-				continue
+				v = dvalue
 			}
-			extendedResources[k] = v
-		}
-		// annotations
-		for k, v := range ruleOut.Annotations {
-			annotations[k] = v
+			nodeOut.ExtendedResources[k] = v
 		}
+		// Add default namespaces to unprefixed names, as nfd-master does by
+		// default
+		nodeOut = nodefeaturerule.WithDefaultNs(nodeOut)
+		src := nodefeaturerule.RuleSource{NodeFeatureRule: nodeFeatureRule.Name, Rule: rule.Name, Priority: rule.Priority}
+		objOut.Add(src, nodeOut)
+		conflicts := output.Add(src, nodeOut)
+		// Feed back rule output for subsequent rules to match, leaving out
+		// labels overridden by a rule of higher priority
+		nodefeaturerule.InsertBackrefs(&nodeFeature.Features, nodeFeatureRule.Name, nodefeaturerule.WithoutLostLabels(ruleOut, src, conflicts, nodefeaturerule.DefaultNsLabelKey))
 	}
+	taints := objOut.Taints
+	labels := objOut.Labels
+	extendedResources := objOut.ExtendedResources
+	annotations := objOut.Annotations
 
 	if len(taints) > 0 {
 		taintValidation := validate.Taints(taints)
@@ -140,7 +180,7 @@ func processNodeFeatureRule(nodeFeatureRule nfdv1alpha1.NodeFeatureRule, nodeFea
 	}
 
 	if len(extendedResources) > 0 {
-		resourceValidation := processExtendedResources(extendedResources, nodeFeature)
+		resourceValidation := processExtendedResources(extendedResources, *nodeFeature)
 		fmt.Println("***\tExtended Resources\t***")
 		for k, v := range extendedResources {
 			fmt.Printf("%s=%s\n", k, v)
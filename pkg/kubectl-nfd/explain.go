@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectlnfd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
+)
+
+// printTrace prints a rule evaluation trace as an indented tree.
+func printTrace(w io.Writer, t *nodefeaturerule.RuleTrace) {
+	fmt.Fprintf(w, "rule %q: %s%s\n", t.Name, matchString(t.Matched), errSuffix(t.Error))
+	if len(t.MatchFeatures) > 0 {
+		fmt.Fprintf(w, "  matchFeatures:\n")
+		printTermTraces(w, t.MatchFeatures, 4)
+	}
+	for i, e := range t.MatchAny {
+		fmt.Fprintf(w, "  matchAny[%d]: %s\n", i, matchString(e.Matched))
+		printTermTraces(w, e.MatchFeatures, 4)
+	}
+}
+
+func printTermTraces(w io.Writer, terms []nodefeaturerule.TermTrace, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, t := range terms {
+		if t.Type != "" {
+			fmt.Fprintf(w, "%s%s (%s): %s%s\n", pad, t.Feature, t.Type, matchString(t.Matched), errSuffix(t.Error))
+		} else {
+			fmt.Fprintf(w, "%s%s: %s%s\n", pad, t.Feature, matchString(t.Matched), errSuffix(t.Error))
+		}
+		printExpressionTraces(w, t.MatchExpressions, t.Type == nodefeaturerule.FlagFeatureType, indent+2)
+		for _, i := range t.Instances {
+			fmt.Fprintf(w, "%s  instance[%d]: %s\n", pad, i.Index, matchString(i.Matched))
+			printExpressionTraces(w, i.MatchExpressions, false, indent+4)
+			if len(i.MatchedNames) > 0 {
+				fmt.Fprintf(w, "%s    matched names: %s\n", pad, strings.Join(i.MatchedNames, ", "))
+			}
+		}
+		if n := t.MatchName; n != nil {
+			fmt.Fprintf(w, "%s  matchName %s: %s%s\n", pad, opString(n.Op, n.Value), matchString(n.Matched), errSuffix(n.Error))
+			if len(n.Names) > 0 {
+				fmt.Fprintf(w, "%s    matched names: %s\n", pad, strings.Join(n.Names, ", "))
+			}
+		}
+	}
+}
+
+func printExpressionTraces(w io.Writer, exprs []nodefeaturerule.ExpressionTrace, isFlag bool, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, e := range exprs {
+		input := "does not exist"
+		if e.Exists {
+			if isFlag {
+				input = "exists"
+			} else {
+				input = fmt.Sprintf("%q", e.Input)
+			}
+		}
+		fmt.Fprintf(w, "%s%s %s: %s (input: %s)%s\n", pad, e.Key, opString(e.Op, e.Value), matchString(e.Matched), input, errSuffix(e.Error))
+	}
+}
+
+func opString(op nfdv1alpha1.MatchOp, value []string) string {
+	if len(value) == 0 {
+		return string(op)
+	}
+	return fmt.Sprintf("%s %v", op, value)
+}
+
+func matchString(matched bool) string {
+	if matched {
+		return "MATCH"
+	}
+	return "NO MATCH"
+}
+
+func errSuffix(err string) string {
+	if err != "" {
+		return " [error: " + err + "]"
+	}
+	return ""
+}
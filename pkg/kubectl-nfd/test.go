@@ -27,16 +27,12 @@ import (
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
 	nfdclientset "sigs.k8s.io/node-feature-discovery/pkg/generated/clientset/versioned"
 	nfdinformers "sigs.k8s.io/node-feature-discovery/pkg/generated/informers/externalversions"
-
-	"sigs.k8s.io/yaml"
 )
 
-func Test(nodefeaturerulepath, nodeName, kubeconfig string) []error {
+func Test(nodefeaturerulepath, nodeName, kubeconfig string, explain bool) []error {
 	var errs []error
 	var err error
 
-	nfr := nfdv1alpha1.NodeFeatureRule{}
-
 	if kubeconfig == "" {
 		kubeconfig = os.Getenv("KUBECONFIG")
 	}
@@ -63,17 +59,12 @@ func Test(nodefeaturerulepath, nodeName, kubeconfig string) []error {
 		}
 	}
 
-	nfrFile, err := os.ReadFile(nodefeaturerulepath)
-	if err != nil {
-		return []error{fmt.Errorf("error reading NodeFeatureRule file: %w", err)}
-	}
-
-	err = yaml.Unmarshal(nfrFile, &nfr)
+	nfrs, err := readNodeFeatureRules(nodefeaturerulepath)
 	if err != nil {
 		return []error{fmt.Errorf("error parsing NodeFeatureRule: %w", err)}
 	}
 
-	errs = append(errs, processNodeFeatureRule(nfr, *features)...)
+	errs = append(errs, processNodeFeatureRules(nfrs, *features, explain)...)
 
 	return errs
 }
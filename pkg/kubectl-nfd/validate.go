@@ -18,30 +18,51 @@ package kubectlnfd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/api/resource"
-	"sigs.k8s.io/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
 	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/validate"
 )
 
 // Given a file path, read the file and check if is a valid NodeFeatureRule file
 func ValidateNFR(filepath string) []error {
-	var err error
 	var validationErr []error
 
-	file, err := os.ReadFile(filepath)
+	nfrs, err := readNodeFeatureRules(filepath)
 	if err != nil {
 		return []error{fmt.Errorf("error reading NodeFeatureRule file: %w", err)}
 	}
 
-	nfr := nfdv1alpha1.NodeFeatureRule{}
-	err = yaml.Unmarshal(file, &nfr)
-	if err != nil {
-		return []error{fmt.Errorf("error reading NodeFeatureRule file: %w", err)}
+	for _, nfr := range nfrs {
+		validationErr = append(validationErr, validateNodeFeatureRule(nfr)...)
+	}
+
+	// Validate the dependencies between the NodeFeatureRule objects
+	if _, err := nodefeaturerule.SortNodeFeatureRules(nfrs); err != nil {
+		validationErr = append(validationErr, err)
+	}
+
+	return validationErr
+}
+
+func validateNodeFeatureRule(nfr *nfdv1alpha1.NodeFeatureRule) []error {
+	var validationErr []error
+
+	// Validate rule references
+	validationErr = append(validationErr, validate.After(nfr)...)
+
+	// Validate node selector
+	if nfr.Spec.NodeSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(nfr.Spec.NodeSelector); err != nil {
+			validationErr = append(validationErr, fmt.Errorf("invalid nodeSelector: %w", err))
+		}
 	}
 
 	for _, rule := range nfr.Spec.Rules {
@@ -83,6 +104,18 @@ func ValidateNFR(filepath string) []error {
 		// Validate VarsTemplate
 		validationErr = append(validationErr, validate.Template(rule.VarsTemplate)...)
 
+		// Validate AnnotationsTemplate
+		validationErr = append(validationErr, validate.Template(rule.AnnotationsTemplate)...)
+
+		// Validate ExtendedResourcesTemplate
+		validationErr = append(validationErr, validate.Template(rule.ExtendedResourcesTemplate)...)
+
+		// Validate TaintsTemplate
+		validationErr = append(validationErr, validate.Template(rule.TaintsTemplate)...)
+
+		// Validate forEachInstance
+		validationErr = append(validationErr, validate.ForEachInstance(&rule)...)
+
 		// Validate matchFeatures
 		validationErr = append(validationErr, validate.MatchFeatures(rule.MatchFeatures)...)
 
@@ -92,3 +125,32 @@ func ValidateNFR(filepath string) []error {
 
 	return validationErr
 }
+
+// readNodeFeatureRules reads NodeFeatureRule objects from a file. The file
+// may contain multiple YAML documents, each holding one object.
+func readNodeFeatureRules(filepath string) ([]*nfdv1alpha1.NodeFeatureRule, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var nfrs []*nfdv1alpha1.NodeFeatureRule
+	decoder := k8syaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		nfr := &nfdv1alpha1.NodeFeatureRule{}
+		if err := decoder.Decode(nfr); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		// Skip empty documents
+		if nfr.Name != "" || len(nfr.Spec.Rules) > 0 {
+			nfrs = append(nfrs, nfr)
+		}
+	}
+	if len(nfrs) == 0 {
+		return nil, fmt.Errorf("no NodeFeatureRule objects found")
+	}
+	return nfrs, nil
+}
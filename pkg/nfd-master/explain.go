@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfdmaster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
+)
+
+// nodeFeatureRuleTrace contains the evaluation traces of the rules of one
+// NodeFeatureRule object.
+type nodeFeatureRuleTrace struct {
+	NodeFeatureRule string                       `json:"nodeFeatureRule"`
+	Rules           []*nodefeaturerule.RuleTrace `json:"rules"`
+	// Skipped is the reason for not evaluating the rules of the object, if
+	// any.
+	Skipped string `json:"skipped,omitempty"`
+	// Conflicts lists the output of the rules of the object conflicting with
+	// the output of other rules.
+	Conflicts []nodefeaturerule.Conflict `json:"conflicts,omitempty"`
+}
+
+// explainNodeFeatureRules evaluates the NodeFeatureRule objects against the
+// features of a node, returning a trace of the evaluation of each rule. If
+// ruleName is non-empty, only the traces of the named NodeFeatureRule object
+// are returned. The rules are evaluated the same way as in the actual node
+// update so that the traces match the output of the node.
+func (m *nfdMaster) explainNodeFeatureRules(nodeName, ruleName string) ([]nodeFeatureRuleTrace, error) {
+	if m.nfdController == nil || m.nfdController.featureLister == nil {
+		return nil, fmt.Errorf("NodeFeature API not enabled")
+	}
+
+	nodeFeatures, err := m.getAndMergeNodeFeatures(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	eval, err := m.evaluateNodeFeatureRules(nodeName, &nodeFeatures.Features, true)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []nodeFeatureRuleTrace{}
+	for _, t := range eval.traces {
+		if ruleName != "" && ruleName != t.NodeFeatureRule {
+			continue
+		}
+		for _, c := range eval.output.Conflicts {
+			if c.Winner.NodeFeatureRule == t.NodeFeatureRule || c.Loser.NodeFeatureRule == t.NodeFeatureRule {
+				t.Conflicts = append(t.Conflicts, c)
+			}
+		}
+		ret = append(ret, t)
+	}
+	return ret, nil
+}
+
+// explainHandler is a http handler returning the evaluation traces of
+// NodeFeatureRule objects for the node specified with the "node" query
+// parameter. The optional "nodefeaturerule" query parameter limits the output
+// to one NodeFeatureRule object.
+func (m *nfdMaster) explainHandler(w http.ResponseWriter, r *http.Request) {
+	nodeName := r.URL.Query().Get("node")
+	if nodeName == "" {
+		http.Error(w, "missing 'node' query parameter", http.StatusBadRequest)
+		return
+	}
+
+	traces, err := m.explainNodeFeatureRules(nodeName, r.URL.Query().Get("nodefeaturerule"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(traces); err != nil {
+		klog.ErrorS(err, "failed to write NodeFeatureRule evaluation traces", "nodeName", nodeName)
+	}
+}
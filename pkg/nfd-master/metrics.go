@@ -32,6 +32,7 @@ const (
 	nodeTaintsRejectedQuery  = "nfd_node_taints_rejected_total"
 	nfrProcessingTimeQuery   = "nfd_nodefeaturerule_processing_duration_seconds"
 	nfrProcessingErrorsQuery = "nfd_nodefeaturerule_processing_errors_total"
+	nfrConflictsQuery        = "nfd_nodefeaturerule_conflicts"
 )
 
 var (
@@ -81,6 +82,17 @@ var (
 		Name: nfrProcessingErrorsQuery,
 		Help: "Number of errors encountered while processing NodeFeatureRule objects.",
 	})
+	nfrConflicts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: nfrConflictsQuery,
+			Help: "Number of conflicting values of node labels, annotations, extended resources and taints produced by NodeFeatureRule objects, in the latest evaluation of the node.",
+		},
+		[]string{
+			"node",
+			"type",
+			"resolution",
+		},
+	)
 )
 
 // registerVersion exposes the Operator build version.
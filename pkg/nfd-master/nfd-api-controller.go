@@ -20,8 +20,13 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	k8sinformers "k8s.io/client-go/informers"
+	k8sclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
@@ -37,11 +42,22 @@ import (
 type nfdController struct {
 	featureLister nfdlisters.NodeFeatureLister
 	ruleLister    nfdlisters.NodeFeatureRuleLister
+	// nodeLister only has the metadata of the nodes, status is dropped
+	nodeLister  corelisters.NodeLister
+	ruleCache   *compiledRuleCache
+	ruleMatches *ruleMatchState
 
 	stopChan chan struct{}
 
 	updateAllNodesChan chan struct{}
 	updateOneNodeChan  chan string
+	updateRuleChan     chan nodeFeatureRuleUpdate
+}
+
+// nodeFeatureRuleUpdate describes a change in a NodeFeatureRule object. Old
+// is nil for added objects and new is nil for deleted objects.
+type nodeFeatureRuleUpdate struct {
+	old, new *nfdv1alpha1.NodeFeatureRule
 }
 
 type nfdApiControllerOptions struct {
@@ -49,11 +65,14 @@ type nfdApiControllerOptions struct {
 	ResyncPeriod       time.Duration
 }
 
-func newNfdController(config *restclient.Config, nfdApiControllerOptions nfdApiControllerOptions) (*nfdController, error) {
+func newNfdController(config *restclient.Config, k8sClient k8sclient.Interface, nfdApiControllerOptions nfdApiControllerOptions) (*nfdController, error) {
 	c := &nfdController{
 		stopChan:           make(chan struct{}, 1),
 		updateAllNodesChan: make(chan struct{}, 1),
 		updateOneNodeChan:  make(chan string),
+		updateRuleChan:     make(chan nodeFeatureRuleUpdate),
+		ruleCache:          newCompiledRuleCache(),
+		ruleMatches:        newRuleMatchState(),
 	}
 
 	nfdClient := nfdclientset.NewForConfigOrDie(config)
@@ -90,23 +109,42 @@ func newNfdController(config *restclient.Config, nfdApiControllerOptions nfdApiC
 	ruleInformer := informerFactory.Nfd().V1alpha1().NodeFeatureRules()
 	if _, err := ruleInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(object interface{}) {
-			klog.V(2).InfoS("NodeFeatureRule added", "nodefeaturerule", klog.KObj(object.(metav1.Object)))
+			nfr := object.(*nfdv1alpha1.NodeFeatureRule)
+			klog.V(2).InfoS("NodeFeatureRule added", "nodefeaturerule", klog.KObj(nfr))
 			if !nfdApiControllerOptions.DisableNodeFeature {
-				c.updateAllNodes()
+				c.updateRule(nil, nfr)
 			}
 			// else: rules will be processed only when gRPC requests are received
 		},
 		UpdateFunc: func(oldObject, newObject interface{}) {
-			klog.V(2).InfoS("NodeFeatureRule updated", "nodefeaturerule", klog.KObj(newObject.(metav1.Object)))
+			oldNfr := oldObject.(*nfdv1alpha1.NodeFeatureRule)
+			newNfr := newObject.(*nfdv1alpha1.NodeFeatureRule)
+			klog.V(2).InfoS("NodeFeatureRule updated", "nodefeaturerule", klog.KObj(newNfr))
 			if !nfdApiControllerOptions.DisableNodeFeature {
-				c.updateAllNodes()
+				if oldNfr.ResourceVersion == newNfr.ResourceVersion {
+					// Periodic resync of the informer, do a full update
+					c.updateAllNodes()
+				} else {
+					c.updateRule(oldNfr, newNfr)
+				}
 			}
 			// else: rules will be processed only when gRPC requests are received
 		},
 		DeleteFunc: func(object interface{}) {
-			klog.V(2).InfoS("NodeFeatureRule deleted", "nodefeaturerule", klog.KObj(object.(metav1.Object)))
+			if tombstone, ok := object.(cache.DeletedFinalStateUnknown); ok {
+				object = tombstone.Obj
+			}
+			nfr, ok := object.(*nfdv1alpha1.NodeFeatureRule)
+			if !ok {
+				klog.InfoS("NodeFeatureRule deleted, unable to determine the object", "object", object)
+				if !nfdApiControllerOptions.DisableNodeFeature {
+					c.updateAllNodes()
+				}
+				return
+			}
+			klog.V(2).InfoS("NodeFeatureRule deleted", "nodefeaturerule", klog.KObj(nfr))
 			if !nfdApiControllerOptions.DisableNodeFeature {
-				c.updateAllNodes()
+				c.updateRule(nfr, nil)
 			}
 			// else: rules will be processed only when gRPC requests are received
 		},
@@ -115,6 +153,30 @@ func newNfdController(config *restclient.Config, nfdApiControllerOptions nfdApiC
 	}
 	c.ruleLister = ruleInformer.Lister()
 
+	// Add informer for Node objects, used for evaluating the nodeSelector of
+	// NodeFeatureRule objects
+	if k8sClient != nil {
+		k8sInformerFactory := k8sinformers.NewSharedInformerFactory(k8sClient, nfdApiControllerOptions.ResyncPeriod)
+		nodeInformer := k8sInformerFactory.Core().V1().Nodes()
+		if err := nodeInformer.Informer().SetTransform(stripNodeStatus); err != nil {
+			return nil, err
+		}
+		if _, err := nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObject, newObject interface{}) {
+				oldNode := oldObject.(*corev1.Node)
+				newNode := newObject.(*corev1.Node)
+				if !nfdApiControllerOptions.DisableNodeFeature && c.nodeSelectorsAffected(oldNode.Labels, newNode.Labels) {
+					klog.V(2).InfoS("node labels affecting nodeSelectors of NodeFeatureRules updated", "nodeName", newNode.Name)
+					c.updateOneNodeChan <- newNode.Name
+				}
+			},
+		}); err != nil {
+			return nil, err
+		}
+		c.nodeLister = nodeInformer.Lister()
+		k8sInformerFactory.Start(c.stopChan)
+	}
+
 	// Start informers
 	informerFactory.Start(c.stopChan)
 
@@ -149,6 +211,53 @@ func getNodeNameForObj(obj metav1.Object) (string, error) {
 	return nodeName, nil
 }
 
+// getNode returns the node object from the informer cache. The status of the
+// node is not available.
+func (c *nfdController) getNode(nodeName string) (*corev1.Node, error) {
+	if c.nodeLister == nil {
+		return nil, fmt.Errorf("node information not available")
+	}
+	return c.nodeLister.Get(nodeName)
+}
+
+// nodeSelectorsAffected returns true if a change in the labels of a node
+// changes the result of the nodeSelector of any NodeFeatureRule object.
+func (c *nfdController) nodeSelectorsAffected(oldLabels, newLabels map[string]string) bool {
+	if k8sLabels.Equals(oldLabels, newLabels) {
+		return false
+	}
+	nfrs, err := c.ruleLister.List(k8sLabels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "failed to list NodeFeatureRule resources")
+		return false
+	}
+	for _, e := range c.ruleCache.get(nfrs) {
+		if !e.hasNodeSelector() {
+			continue
+		}
+		oldMatch, _ := e.matchesNode(oldLabels)
+		newMatch, _ := e.matchesNode(newLabels)
+		if oldMatch != newMatch {
+			return true
+		}
+	}
+	return false
+}
+
+// stripNodeStatus drops the status and managed fields of node objects to
+// reduce the memory footprint of the informer cache.
+func stripNodeStatus(obj interface{}) (interface{}, error) {
+	if node, ok := obj.(*corev1.Node); ok {
+		node.ManagedFields = nil
+		node.Status = corev1.NodeStatus{}
+	}
+	return obj, nil
+}
+
+func (c *nfdController) updateRule(old, new *nfdv1alpha1.NodeFeatureRule) {
+	c.updateRuleChan <- nodeFeatureRuleUpdate{old: old, new: new}
+}
+
 func (c *nfdController) updateAllNodes() {
 	select {
 	case c.updateAllNodesChan <- struct{}{}:
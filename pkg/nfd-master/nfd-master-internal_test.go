@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"maps"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -28,6 +30,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	. "github.com/smartystreets/goconvey/convey"
 	"golang.org/x/net/context"
 
@@ -35,13 +38,17 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	k8sinformers "k8s.io/client-go/informers"
 	k8sclient "k8s.io/client-go/kubernetes"
 	fakeclient "k8s.io/client-go/kubernetes/fake"
 	fakecorev1client "k8s.io/client-go/kubernetes/typed/core/v1/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
 	fakenfdclient "sigs.k8s.io/node-feature-discovery/pkg/generated/clientset/versioned/fake"
@@ -64,11 +71,14 @@ func newTestNode() *corev1.Node {
 	return &n
 }
 
-func newFakeNfdAPIController(client *fakenfdclient.Clientset) *nfdController {
+func newFakeNfdAPIController(client *fakenfdclient.Clientset, k8sClient k8sclient.Interface) *nfdController {
 	c := &nfdController{
 		stopChan:           make(chan struct{}, 1),
 		updateAllNodesChan: make(chan struct{}, 1),
 		updateOneNodeChan:  make(chan string),
+		updateRuleChan:     make(chan nodeFeatureRuleUpdate),
+		ruleCache:          newCompiledRuleCache(),
+		ruleMatches:        newRuleMatchState(),
 	}
 
 	informerFactory := nfdinformers.NewSharedInformerFactory(client, 1*time.Hour)
@@ -95,6 +105,12 @@ func newFakeNfdAPIController(client *fakenfdclient.Clientset) *nfdController {
 	}
 	c.ruleLister = ruleInformer.Lister()
 
+	// Add informer for Node objects
+	k8sInformerFactory := k8sinformers.NewSharedInformerFactory(k8sClient, 1*time.Hour)
+	nodeInformer := k8sInformerFactory.Core().V1().Nodes()
+	c.nodeLister = nodeInformer.Lister()
+	k8sInformerFactory.Start(c.stopChan)
+
 	// Start informers
 	informerFactory.Start(c.stopChan)
 
@@ -761,7 +777,7 @@ func newTestNodeList() *corev1.NodeList {
 func BenchmarkNfdAPIUpdateAllNodes(b *testing.B) {
 	fakeCli := fakeclient.NewSimpleClientset(newTestNodeList())
 	fakeMaster := newFakeMaster(fakeCli)
-	fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset())
+	fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(), fakeMaster.k8sClient)
 
 	nodeUpdaterPool := newNodeUpdaterPool(fakeMaster)
 	fakeMaster.nodeUpdaterPool = nodeUpdaterPool
@@ -884,3 +900,511 @@ func TestGetDynamicValue(t *testing.T) {
 		})
 	}
 }
+
+func TestExplainNodeFeatureRules(t *testing.T) {
+	Convey("When explaining NodeFeatureRules for a node", t, func() {
+		nf := &nfdv1alpha1.NodeFeature{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nf-1",
+				Namespace: "default",
+				Labels:    map[string]string{nfdv1alpha1.NodeFeatureObjNodeNameLabel: testNodeName},
+			},
+			Spec: nfdv1alpha1.NodeFeatureSpec{Features: *nfdv1alpha1.NewFeatures()},
+		}
+		nf.Spec.Features.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{"major": "6"})
+
+		nfr := &nfdv1alpha1.NodeFeatureRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "nfr-1"},
+			Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+				Rules: []nfdv1alpha1.Rule{
+					{
+						Name:   "rule-1",
+						Labels: map[string]string{"feature.node.kubernetes.io/kernel-6": "true"},
+						MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+							Feature:          "kernel.version",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"major": {Op: nfdv1alpha1.MatchIn, Value: []string{"6"}}},
+						}},
+					},
+					{
+						Name:   "rule-2",
+						Labels: map[string]string{"feature.node.kubernetes.io/backref": "true"},
+						MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+							Feature:          "rule.matched",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"feature.node.kubernetes.io/kernel-6": {Op: nfdv1alpha1.MatchIsTrue}},
+						}},
+					},
+				},
+			},
+		}
+
+		fakeMaster := newFakeMaster(fakeclient.NewSimpleClientset())
+		fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(nf, nfr), fakeMaster.k8sClient)
+		defer close(fakeMaster.nfdController.stopChan)
+
+		So(func() interface{} {
+			rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything())
+			features, _ := fakeMaster.nfdController.featureLister.List(k8sLabels.Everything())
+			return len(rules) + len(features)
+		}, withTimeout, 2*time.Second, ShouldEqual, 2)
+
+		Convey("traces of all rules should be returned", func() {
+			traces, err := fakeMaster.explainNodeFeatureRules(testNodeName, "")
+			So(err, ShouldBeNil)
+			So(len(traces), ShouldEqual, 1)
+			So(traces[0].NodeFeatureRule, ShouldEqual, "nfr-1")
+			So(len(traces[0].Rules), ShouldEqual, 2)
+			So(traces[0].Rules[0].Matched, ShouldBeTrue)
+			So(traces[0].Rules[0].MatchFeatures[0].MatchExpressions[0].Input, ShouldEqual, "6")
+			// Backreferences should be resolved
+			So(traces[0].Rules[1].Matched, ShouldBeTrue)
+		})
+
+		Convey("traces should be limited to the requested NodeFeatureRule", func() {
+			traces, err := fakeMaster.explainNodeFeatureRules(testNodeName, "non-existent")
+			So(err, ShouldBeNil)
+			So(traces, ShouldBeEmpty)
+		})
+
+		Convey("the http handler should return the traces as JSON", func() {
+			rec := httptest.NewRecorder()
+			fakeMaster.explainHandler(rec, httptest.NewRequest(http.MethodGet, "/debug/explain?node="+testNodeName, nil))
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(rec.Body.String(), ShouldContainSubstring, `"name":"rule-1","matched":true`)
+
+			rec = httptest.NewRecorder()
+			fakeMaster.explainHandler(rec, httptest.NewRequest(http.MethodGet, "/debug/explain", nil))
+			So(rec.Code, ShouldEqual, http.StatusBadRequest)
+		})
+	})
+}
+
+func TestCompiledRuleCache(t *testing.T) {
+	Convey("When getting compiled rules from the cache", t, func() {
+		cache := newCompiledRuleCache()
+		nfr := &nfdv1alpha1.NodeFeatureRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "nfr-1", UID: "uid-1", Generation: 1},
+			Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+				Rules: []nfdv1alpha1.Rule{{Name: "rule-1"}, {Name: "rule-2"}},
+			},
+		}
+		compiled := cache.get([]*nfdv1alpha1.NodeFeatureRule{nfr})
+		So(len(compiled), ShouldEqual, 1)
+		So(len(compiled[0].rules), ShouldEqual, 2)
+		So(compiled[0].rules[1].Rule().Name, ShouldEqual, "rule-2")
+
+		Convey("the same object should not be re-compiled", func() {
+			c := cache.get([]*nfdv1alpha1.NodeFeatureRule{nfr})
+			So(c[0].rules[0], ShouldPointTo, compiled[0].rules[0])
+		})
+		Convey("a copy of the same generation should not be re-compiled", func() {
+			c := cache.get([]*nfdv1alpha1.NodeFeatureRule{nfr.DeepCopy()})
+			So(c[0].rules[0], ShouldPointTo, compiled[0].rules[0])
+		})
+		Convey("a new generation should be re-compiled", func() {
+			nfrNew := nfr.DeepCopy()
+			nfrNew.Generation = 2
+			nfrNew.Spec.Rules[0].Name = "rule-1-new"
+			c := cache.get([]*nfdv1alpha1.NodeFeatureRule{nfrNew})
+			So(c[0].rules[0], ShouldNotPointTo, compiled[0].rules[0])
+			So(c[0].rules[0].Rule().Name, ShouldEqual, "rule-1-new")
+		})
+		Convey("a re-created object should be re-compiled", func() {
+			nfrNew := nfr.DeepCopy()
+			nfrNew.UID = "uid-2"
+			c := cache.get([]*nfdv1alpha1.NodeFeatureRule{nfrNew})
+			So(c[0].rules[0], ShouldNotPointTo, compiled[0].rules[0])
+		})
+		Convey("deleted objects should be dropped from the cache", func() {
+			So(cache.get(nil), ShouldBeEmpty)
+			So(cache.entries, ShouldBeEmpty)
+		})
+		Convey("objects should be returned in evaluation order", func() {
+			nfr0 := &nfdv1alpha1.NodeFeatureRule{
+				ObjectMeta: metav1.ObjectMeta{Name: "nfr-0"},
+				Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+					Rules: []nfdv1alpha1.Rule{{Name: "rule-1", After: []string{"nfr-1/rule-2"}}},
+				},
+			}
+			c := cache.get([]*nfdv1alpha1.NodeFeatureRule{nfr0, nfr})
+			So(len(c), ShouldEqual, 2)
+			So(c[0].nfr.Name, ShouldEqual, "nfr-1")
+			So(c[1].nfr.Name, ShouldEqual, "nfr-0")
+		})
+	})
+}
+
+func TestNodesAffectedByRuleUpdates(t *testing.T) {
+	Convey("When determining the nodes affected by NodeFeatureRule changes", t, func() {
+		newNodeFeature := func(nodeName, major string) *nfdv1alpha1.NodeFeature {
+			nf := &nfdv1alpha1.NodeFeature{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nf-" + nodeName,
+					Namespace: "default",
+					Labels:    map[string]string{nfdv1alpha1.NodeFeatureObjNodeNameLabel: nodeName},
+				},
+				Spec: nfdv1alpha1.NodeFeatureSpec{Features: *nfdv1alpha1.NewFeatures()},
+			}
+			nf.Spec.Features.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{"major": major})
+			return nf
+		}
+		newRule := func(major string) *nfdv1alpha1.NodeFeatureRule {
+			return &nfdv1alpha1.NodeFeatureRule{
+				ObjectMeta: metav1.ObjectMeta{Name: "nfr-1"},
+				Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+					Rules: []nfdv1alpha1.Rule{{
+						Name:   "rule-1",
+						Labels: map[string]string{"feature.node.kubernetes.io/kernel": major},
+						MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+							Feature:          "kernel.version",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"major": {Op: nfdv1alpha1.MatchIn, Value: []string{major}}},
+						}},
+					}},
+				},
+			}
+		}
+		nodes := make([]corev1.Node, 4)
+		for i := range nodes {
+			nodes[i].Name = fmt.Sprintf("node-%d", i+1)
+		}
+
+		oldRule := newRule("5")
+		fakeMaster := newFakeMaster(fakeclient.NewSimpleClientset())
+		fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(
+			oldRule, newNodeFeature("node-1", "6"), newNodeFeature("node-2", "5"), newNodeFeature("node-4", "6")), fakeMaster.k8sClient)
+		defer close(fakeMaster.nfdController.stopChan)
+
+		So(func() interface{} {
+			rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything())
+			features, _ := fakeMaster.nfdController.featureLister.List(k8sLabels.Everything())
+			return len(rules) + len(features)
+		}, withTimeout, 2*time.Second, ShouldEqual, 4)
+
+		// Process nodes 1-3, leaving node-4 unprocessed
+		for _, n := range nodes[:3] {
+			features, err := fakeMaster.getAndMergeNodeFeatures(n.Name)
+			So(err, ShouldBeNil)
+			fakeMaster.processNodeFeatureRule(n.Name, &features.Features)
+		}
+
+		Convey("the match state of processed nodes should be recorded", func() {
+			matched, known := fakeMaster.nfdController.ruleMatches.get("node-1", "nfr-1")
+			So(known, ShouldBeTrue)
+			So(matched, ShouldBeFalse)
+			matched, known = fakeMaster.nfdController.ruleMatches.get("node-2", "nfr-1")
+			So(known, ShouldBeTrue)
+			So(matched, ShouldBeTrue)
+			_, known = fakeMaster.nfdController.ruleMatches.get("node-4", "nfr-1")
+			So(known, ShouldBeFalse)
+		})
+
+		Convey("nodes matched by the old or the new rule, and unprocessed nodes, should be affected", func() {
+			affected := fakeMaster.nodesAffectedByRuleUpdates(nodes, []nodeFeatureRuleUpdate{{old: oldRule, new: newRule("6")}})
+			So(affected, ShouldResemble, []string{"node-1", "node-2", "node-4"})
+		})
+
+		Convey("only nodes matched by a deleted rule should be affected", func() {
+			affected := fakeMaster.nodesAffectedByRuleUpdates(nodes[:3], []nodeFeatureRuleUpdate{{old: oldRule}})
+			So(affected, ShouldResemble, []string{"node-2"})
+		})
+
+		Convey("only nodes matched by an added rule should be affected", func() {
+			nfr := newRule("6")
+			nfr.Name = "nfr-2"
+			affected := fakeMaster.nodesAffectedByRuleUpdates(nodes[:3], []nodeFeatureRuleUpdate{{new: nfr}})
+			So(affected, ShouldResemble, []string{"node-1"})
+		})
+
+		Convey("the state of removed nodes should be dropped", func() {
+			fakeMaster.nfdController.ruleMatches.retain(nodes[1:])
+			_, known := fakeMaster.nfdController.ruleMatches.get("node-1", "nfr-1")
+			So(known, ShouldBeFalse)
+		})
+
+		Convey("rules using backreferences should be detected", func() {
+			nfr := newRule("6")
+			So(usesRuleBackrefs(nfr), ShouldBeFalse)
+			nfr.Spec.Rules[0].MatchAny = []nfdv1alpha1.MatchAnyElem{{MatchFeatures: nfdv1alpha1.FeatureMatcher{{Feature: "rule.matched"}}}}
+			So(usesRuleBackrefs(nfr), ShouldBeTrue)
+			nfr.Spec.Rules[0].MatchAny = []nfdv1alpha1.MatchAnyElem{{MatchFeatures: nfdv1alpha1.FeatureMatcher{{Feature: "rule.nfr-2"}}}}
+			So(usesRuleBackrefs(nfr), ShouldBeTrue)
+		})
+	})
+}
+
+func TestProcessNodeFeatureRuleOrder(t *testing.T) {
+	Convey("When processing NodeFeatureRules with dependencies", t, func() {
+		// nfr-a uses the output of nfr-b so it must be evaluated after it,
+		// contrary to the alphabetical order
+		nfrA := &nfdv1alpha1.NodeFeatureRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "nfr-a"},
+			Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+				Rules: []nfdv1alpha1.Rule{{
+					Name:   "rule-a",
+					After:  []string{"nfr-b/rule-b"},
+					Labels: map[string]string{"feature.node.kubernetes.io/a": "true"},
+					MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+						Feature:          "rule.nfr-b",
+						MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"var-b": {Op: nfdv1alpha1.MatchIsTrue}},
+					}},
+				}},
+			},
+		}
+		nfrB := &nfdv1alpha1.NodeFeatureRule{
+			ObjectMeta: metav1.ObjectMeta{Name: "nfr-b"},
+			Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+				Rules: []nfdv1alpha1.Rule{{
+					Name: "rule-b",
+					Vars: map[string]string{"var-b": "true"},
+				}},
+			},
+		}
+
+		fakeMaster := newFakeMaster(fakeclient.NewSimpleClientset())
+		fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(nfrA, nfrB), fakeMaster.k8sClient)
+		defer close(fakeMaster.nfdController.stopChan)
+
+		So(func() interface{} {
+			rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything())
+			return len(rules)
+		}, withTimeout, 2*time.Second, ShouldEqual, 2)
+
+		Convey("rules should be evaluated in dependency order", func() {
+			features := nfdv1alpha1.NewFeatures()
+			labels, _, _, _ := fakeMaster.processNodeFeatureRule(testNodeName, features)
+			So(labels, ShouldResemble, Labels{"feature.node.kubernetes.io/a": "true"})
+			So(features.Attributes["rule.nfr-b"].Elements, ShouldResemble, map[string]string{"var-b": "true"})
+		})
+	})
+}
+
+func TestProcessNodeFeatureRuleNodeSelector(t *testing.T) {
+	Convey("When processing NodeFeatureRules with a nodeSelector", t, func() {
+		newRule := func(name string, selector *metav1.LabelSelector) *nfdv1alpha1.NodeFeatureRule {
+			return &nfdv1alpha1.NodeFeatureRule{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+					NodeSelector: selector,
+					Rules: []nfdv1alpha1.Rule{{
+						Name:   "rule-1",
+						Labels: map[string]string{"feature.node.kubernetes.io/" + name: "true"},
+					}},
+				},
+			}
+		}
+		node := newTestNode()
+		node.Labels["pool"] = "gpu"
+
+		fakeMaster := newFakeMaster(fakeclient.NewSimpleClientset(node))
+		fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(
+			newRule("nfr-all", nil),
+			newRule("nfr-empty", &metav1.LabelSelector{}),
+			newRule("nfr-gpu", &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "gpu"}}),
+			newRule("nfr-edge", &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "edge"}}),
+			newRule("nfr-invalid", &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "pool", Operator: "invalid"}}}),
+		), fakeMaster.k8sClient)
+		defer close(fakeMaster.nfdController.stopChan)
+
+		So(func() interface{} {
+			rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything())
+			nodes, _ := fakeMaster.nfdController.nodeLister.List(k8sLabels.Everything())
+			return len(rules) + len(nodes)
+		}, withTimeout, 2*time.Second, ShouldEqual, 6)
+
+		Convey("only objects whose nodeSelector matches the node should be evaluated", func() {
+			labels, _, _, _ := fakeMaster.processNodeFeatureRule(testNodeName, nfdv1alpha1.NewFeatures())
+			So(labels, ShouldResemble, Labels{
+				"feature.node.kubernetes.io/nfr-all":   "true",
+				"feature.node.kubernetes.io/nfr-empty": "true",
+				"feature.node.kubernetes.io/nfr-gpu":   "true",
+			})
+			matched, _ := fakeMaster.nfdController.ruleMatches.get(testNodeName, "nfr-edge")
+			So(matched, ShouldBeFalse)
+		})
+
+		Convey("objects with a nodeSelector should be skipped for non-existent nodes", func() {
+			labels, _, _, _ := fakeMaster.processNodeFeatureRule("non-existent", nfdv1alpha1.NewFeatures())
+			So(labels, ShouldResemble, Labels{"feature.node.kubernetes.io/nfr-all": "true"})
+		})
+
+		Convey("nodes outside the nodeSelector should not be affected by changes", func() {
+			nodes := []corev1.Node{*node, {ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{"pool": "edge"}}}}
+			for _, n := range nodes {
+				fakeMaster.nfdController.ruleMatches.set(n.Name, map[string]struct{}{})
+			}
+			affected := fakeMaster.nodesAffectedByRuleUpdates(nodes, []nodeFeatureRuleUpdate{{new: newRule("nfr-new", &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "gpu"}})}})
+			So(affected, ShouldResemble, []string{testNodeName})
+		})
+
+		Convey("only node label changes affecting a nodeSelector should trigger an update", func() {
+			So(fakeMaster.nfdController.nodeSelectorsAffected(map[string]string{"pool": "gpu"}, map[string]string{"pool": "gpu"}), ShouldBeFalse)
+			So(fakeMaster.nfdController.nodeSelectorsAffected(map[string]string{"pool": "gpu"}, map[string]string{"pool": "gpu", "foo": "bar"}), ShouldBeFalse)
+			So(fakeMaster.nfdController.nodeSelectorsAffected(map[string]string{"pool": "gpu"}, map[string]string{"pool": "edge"}), ShouldBeTrue)
+			So(fakeMaster.nfdController.nodeSelectorsAffected(map[string]string{}, map[string]string{"pool": "gpu"}), ShouldBeTrue)
+		})
+
+		Convey("the explain output should show skipped objects", func() {
+			traces, err := fakeMaster.explainNodeFeatureRules(testNodeName, "nfr-edge")
+			So(err, ShouldBeNil)
+			So(len(traces), ShouldEqual, 1)
+			So(traces[0].Skipped, ShouldEqual, "nodeSelector does not match the node")
+			So(traces[0].Rules, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestProcessNodeFeatureRuleConflicts(t *testing.T) {
+	Convey("When processing NodeFeatureRules with conflicting output", t, func() {
+		newRule := func(name string, priority int32, value string) *nfdv1alpha1.NodeFeatureRule {
+			return &nfdv1alpha1.NodeFeatureRule{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+					Rules: []nfdv1alpha1.Rule{{
+						Name:     "rule-1",
+						Priority: priority,
+						Labels:   map[string]string{"feature.node.kubernetes.io/gpu": value, "feature.node.kubernetes.io/" + name: "true"},
+						Taints:   []corev1.Taint{{Key: "example.com/gpu", Value: value, Effect: corev1.TaintEffectNoSchedule}},
+					}},
+				},
+			}
+		}
+
+		fakeMaster := newFakeMaster(fakeclient.NewSimpleClientset())
+		fakeRecorder := record.NewFakeRecorder(10)
+		fakeMaster.eventRecorder = fakeRecorder
+
+		Convey("the rule evaluated last should win between rules of equal priority", func() {
+			fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(newRule("nfr-a", 0, "a"), newRule("nfr-b", 0, "b")), fakeMaster.k8sClient)
+			defer close(fakeMaster.nfdController.stopChan)
+			So(func() interface{} {
+				rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything())
+				return len(rules)
+			}, withTimeout, 2*time.Second, ShouldEqual, 2)
+
+			labels, _, _, taints := fakeMaster.processNodeFeatureRule(testNodeName, nfdv1alpha1.NewFeatures())
+			So(labels, ShouldResemble, Labels{
+				"feature.node.kubernetes.io/gpu":   "b",
+				"feature.node.kubernetes.io/nfr-a": "true",
+				"feature.node.kubernetes.io/nfr-b": "true",
+			})
+			So(taints, ShouldResemble, []corev1.Taint{{Key: "example.com/gpu", Value: "b", Effect: corev1.TaintEffectNoSchedule}})
+			So(testutil.ToFloat64(nfrConflicts.WithLabelValues(testNodeName, "label", "order")), ShouldEqual, 1)
+			So(testutil.ToFloat64(nfrConflicts.WithLabelValues(testNodeName, "taint", "order")), ShouldEqual, 1)
+			// One event for both of the objects for both of the conflicts
+			So(len(fakeRecorder.Events), ShouldEqual, 4)
+			So(<-fakeRecorder.Events, ShouldStartWith, "Warning OutputConflict conflicting output, first detected on node "+testNodeName)
+
+			// The same conflicts are not reported again
+			for len(fakeRecorder.Events) > 0 {
+				<-fakeRecorder.Events
+			}
+			_, _, _, _ = fakeMaster.processNodeFeatureRule("node-2", nfdv1alpha1.NewFeatures())
+			So(fakeRecorder.Events, ShouldBeEmpty)
+
+			traces, err := fakeMaster.explainNodeFeatureRules(testNodeName, "nfr-a")
+			So(err, ShouldBeNil)
+			So(len(traces), ShouldEqual, 1)
+			So(traces[0].Conflicts, ShouldHaveLength, 2)
+			So(traces[0].Conflicts[0].Loser.NodeFeatureRule, ShouldEqual, "nfr-a")
+		})
+
+		Convey("the rule with the highest priority should win", func() {
+			// Rule matching against the output of the preceding objects
+			nfrC := &nfdv1alpha1.NodeFeatureRule{
+				ObjectMeta: metav1.ObjectMeta{Name: "nfr-c"},
+				Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+					Rules: []nfdv1alpha1.Rule{{
+						Name:   "rule-1",
+						Labels: map[string]string{"feature.node.kubernetes.io/gpu-a": "true"},
+						MatchFeatures: nfdv1alpha1.FeatureMatcher{{
+							Feature:          "rule.matched",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{"feature.node.kubernetes.io/gpu": {Op: nfdv1alpha1.MatchIn, Value: []string{"a"}}},
+						}},
+					}},
+				},
+			}
+			fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(newRule("nfr-a", 10, "a"), newRule("nfr-b", 0, "b"), nfrC), fakeMaster.k8sClient)
+			defer close(fakeMaster.nfdController.stopChan)
+			So(func() interface{} {
+				rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything())
+				return len(rules)
+			}, withTimeout, 2*time.Second, ShouldEqual, 3)
+
+			labels, _, _, taints := fakeMaster.processNodeFeatureRule(testNodeName, nfdv1alpha1.NewFeatures())
+			So(labels["feature.node.kubernetes.io/gpu"], ShouldEqual, "a")
+			// Backreferences see the effective value of the label
+			So(labels["feature.node.kubernetes.io/gpu-a"], ShouldEqual, "true")
+			So(taints, ShouldResemble, []corev1.Taint{{Key: "example.com/gpu", Value: "a", Effect: corev1.TaintEffectNoSchedule}})
+			// The conflicts of the latest evaluation are reported
+			So(testutil.ToFloat64(nfrConflicts.WithLabelValues(testNodeName, "label", "order")), ShouldEqual, 0)
+			So(testutil.ToFloat64(nfrConflicts.WithLabelValues(testNodeName, "label", "priority")), ShouldEqual, 1)
+			// Conflicts resolved by priority are not recorded as events
+			So(fakeRecorder.Events, ShouldBeEmpty)
+		})
+	})
+}
+
+func BenchmarkProcessNodeFeatureRule(b *testing.B) {
+	const numRules, numNodes = 20, 2000
+
+	nfrs := make([]runtime.Object, numRules)
+	for i := range nfrs {
+		nfrs[i] = &nfdv1alpha1.NodeFeatureRule{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("nfr-%d", i), UID: k8stypes.UID(fmt.Sprintf("uid-%d", i)), Generation: 1},
+			Spec: nfdv1alpha1.NodeFeatureRuleSpec{
+				Rules: []nfdv1alpha1.Rule{{
+					Name:           fmt.Sprintf("rule-%d", i),
+					Labels:         map[string]string{fmt.Sprintf("feature-%d", i): "true"},
+					LabelsTemplate: "{{ range .pci.device }}vendor-{{ .vendor }}=true\n{{ end }}",
+					MatchFeatures: nfdv1alpha1.FeatureMatcher{
+						{
+							Feature: "kernel.version",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+								"major": {Op: nfdv1alpha1.MatchGt, Value: []string{"4"}},
+								"minor": {Op: nfdv1alpha1.MatchGtLt, Value: []string{"-1", fmt.Sprint(100 + i)}},
+							},
+						},
+						{
+							Feature: "pci.device",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+								"vendor": {Op: nfdv1alpha1.MatchInRegexp, Value: []string{"^8086$", "^10de$", "^1002$"}},
+								"class":  {Op: nfdv1alpha1.MatchInRegexp, Value: []string{"^03"}},
+							},
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	vendors := []string{"8086", "10de", "1002", "15b3"}
+	features := make([]*nfdv1alpha1.Features, numNodes)
+	for i := range features {
+		f := nfdv1alpha1.NewFeatures()
+		f.Attributes["kernel.version"] = nfdv1alpha1.NewAttributeFeatures(map[string]string{
+			"major": fmt.Sprint(4 + i%3),
+			"minor": fmt.Sprint(i % 20),
+		})
+		f.Instances["pci.device"] = nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+			*nfdv1alpha1.NewInstanceFeature(map[string]string{"vendor": vendors[i%len(vendors)], "class": "0300"}),
+		})
+		features[i] = f
+	}
+
+	fakeMaster := newFakeMaster(fakeclient.NewSimpleClientset())
+	fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(nfrs...), fakeMaster.k8sClient)
+	defer close(fakeMaster.nfdController.stopChan)
+	for {
+		if rules, _ := fakeMaster.nfdController.ruleLister.List(k8sLabels.Everything()); len(rules) == numRules {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for j, f := range features {
+			_, _, _, _ = fakeMaster.processNodeFeatureRule(fmt.Sprintf("node-%d", j), f)
+		}
+	}
+}
@@ -44,8 +44,10 @@ import (
 	k8sLabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	k8sclient "k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	controller "k8s.io/kubernetes/pkg/controller"
 	klogutils "sigs.k8s.io/node-feature-discovery/pkg/utils/klog"
@@ -56,6 +58,7 @@ import (
 	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
 	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
 	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/validate"
+	nfdscheme "sigs.k8s.io/node-feature-discovery/pkg/generated/clientset/versioned/scheme"
 	pb "sigs.k8s.io/node-feature-discovery/pkg/labeler"
 	"sigs.k8s.io/node-feature-discovery/pkg/utils"
 	"sigs.k8s.io/node-feature-discovery/pkg/version"
@@ -123,6 +126,7 @@ type Args struct {
 	VerifyNodeName       bool
 	Options              string
 	EnableLeaderElection bool
+	EnableExplain        bool
 	MetricsPort          int
 
 	Overrides ConfigOverrideArgs
@@ -151,6 +155,7 @@ type nfdMaster struct {
 	stop            chan struct{}
 	ready           chan bool
 	k8sClient       k8sclient.Interface
+	eventRecorder   record.EventRecorder
 	nodeUpdaterPool *nodeUpdaterPool
 	deniedNs
 	config *NFDConfig
@@ -239,6 +244,13 @@ func (m *nfdMaster) Run() error {
 		}
 	}
 
+	if m.k8sClient != nil {
+		eventBroadcaster := record.NewBroadcaster()
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: m.k8sClient.CoreV1().Events("")})
+		defer eventBroadcaster.Shutdown()
+		m.eventRecorder = eventBroadcaster.NewRecorder(nfdscheme.Scheme, corev1.EventSource{Component: "nfd-master", Host: m.nodeName})
+	}
+
 	m.nodeUpdaterPool.start(m.config.NfdApiParallelism)
 
 	// Create watcher for config file
@@ -256,7 +268,7 @@ func (m *nfdMaster) Run() error {
 
 	// Register to metrics server
 	if m.args.MetricsPort > 0 {
-		m := utils.CreateMetricsServer(m.args.MetricsPort,
+		ms := utils.CreateMetricsServer(m.args.MetricsPort,
 			buildInfo,
 			nodeUpdateRequests,
 			nodeUpdates,
@@ -265,10 +277,16 @@ func (m *nfdMaster) Run() error {
 			nodeERsRejected,
 			nodeTaintsRejected,
 			nfrProcessingTime,
-			nfrProcessingErrors)
-		go m.Run()
+			nfrProcessingErrors,
+			nfrConflicts)
+		// The explain endpoint exposes the features of any node without
+		// authentication, so it must be explicitly enabled
+		if m.args.EnableExplain {
+			ms.HandleFunc("/debug/explain", m.explainHandler)
+		}
+		go ms.Run()
 		registerVersion(version.Get())
-		defer m.Stop()
+		defer ms.Stop()
 	}
 
 	// Run gRPC server
@@ -426,6 +444,7 @@ func (m *nfdMaster) nfdAPIUpdateHandler() {
 	// disabled (i.e. NodeFeature API is enabled)
 	updateAll := m.args.EnableNodeFeatureApi
 	updateNodes := make(map[string]struct{})
+	var updateRules []nodeFeatureRuleUpdate
 	rateLimit := time.After(time.Second)
 	for {
 		select {
@@ -433,6 +452,8 @@ func (m *nfdMaster) nfdAPIUpdateHandler() {
 			updateAll = true
 		case nodeName := <-m.nfdController.updateOneNodeChan:
 			updateNodes[nodeName] = struct{}{}
+		case u := <-m.nfdController.updateRuleChan:
+			updateRules = append(updateRules, u)
 		case <-rateLimit:
 			errUpdateAll := false
 			if updateAll {
@@ -441,6 +462,12 @@ func (m *nfdMaster) nfdAPIUpdateHandler() {
 					errUpdateAll = true
 				}
 			} else {
+				if len(updateRules) > 0 {
+					if err := m.nfdAPIUpdateNodesForRules(updateRules); err != nil {
+						klog.ErrorS(err, "failed to update nodes affected by NodeFeatureRule changes")
+						errUpdateAll = true
+					}
+				}
 				for nodeName := range updateNodes {
 					m.nodeUpdaterPool.queue.Add(nodeName)
 				}
@@ -449,6 +476,7 @@ func (m *nfdMaster) nfdAPIUpdateHandler() {
 			// Reset "work queue" and timer
 			updateAll = errUpdateAll
 			updateNodes = map[string]struct{}{}
+			updateRules = nil
 			rateLimit = time.After(time.Second)
 		}
 	}
@@ -709,6 +737,8 @@ func (m *nfdMaster) nfdAPIUpdateAllNodes() error {
 		return err
 	}
 
+	m.nfdController.ruleMatches.retain(nodes.Items)
+
 	for _, node := range nodes.Items {
 		m.nodeUpdaterPool.queue.Add(node.Name)
 	}
@@ -721,10 +751,34 @@ func (m *nfdMaster) nfdAPIUpdateOneNode(nodeName string) error {
 		return nil
 	}
 
+	if m.config.NoPublish {
+		return nil
+	}
+
+	klog.V(1).InfoS("processing of node initiated by NodeFeature API", "nodeName", nodeName)
+
+	features, err := m.getAndMergeNodeFeatures(nodeName)
+	if err != nil {
+		return err
+	}
+
+	// Update node labels et al. This may also mean removing all NFD-owned
+	// labels (et al.), for example  in the case no NodeFeature objects are
+	// present.
+	if err := m.refreshNodeFeatures(nodeName, features.Labels, &features.Features); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getAndMergeNodeFeatures merges the NodeFeature objects of the given node
+// into a single NodeFeatureSpec.
+func (m *nfdMaster) getAndMergeNodeFeatures(nodeName string) (*nfdv1alpha1.NodeFeatureSpec, error) {
 	sel := k8sLabels.SelectorFromSet(k8sLabels.Set{nfdv1alpha1.NodeFeatureObjNodeNameLabel: nodeName})
 	objs, err := m.nfdController.featureLister.List(sel)
 	if err != nil {
-		return fmt.Errorf("failed to get NodeFeature resources for node %q: %w", nodeName, err)
+		return nil, fmt.Errorf("failed to get NodeFeature resources for node %q: %w", nodeName, err)
 	}
 
 	// Sort our objects
@@ -744,12 +798,6 @@ func (m *nfdMaster) nfdAPIUpdateOneNode(nodeName string) error {
 		return objs[i].Namespace < objs[j].Namespace
 	})
 
-	if m.config.NoPublish {
-		return nil
-	}
-
-	klog.V(1).InfoS("processing of node initiated by NodeFeature API", "nodeName", nodeName)
-
 	features := nfdv1alpha1.NewNodeFeatureSpec()
 
 	if len(objs) > 0 {
@@ -772,14 +820,7 @@ func (m *nfdMaster) nfdAPIUpdateOneNode(nodeName string) error {
 		klog.V(4).InfoS("merged nodeFeatureSpecs", "newNodeFeatureSpec", utils.DelayedDumper(features))
 	}
 
-	// Update node labels et al. This may also mean removing all NFD-owned
-	// labels (et al.), for example  in the case no NodeFeature objects are
-	// present.
-	if err := m.refreshNodeFeatures(nodeName, features.Labels, &features.Features); err != nil {
-		return err
-	}
-
-	return nil
+	return features, nil
 }
 
 // filterExtendedResources filters extended resources and returns a map
@@ -964,23 +1005,81 @@ func (m *nfdMaster) processNodeFeatureRule(nodeName string, features *nfdv1alpha
 		return nil, nil, nil, nil
 	}
 
-	extendedResources := ExtendedResources{}
-	labels := make(map[string]string)
-	annotations := make(map[string]string)
-	var taints []corev1.Taint
-	ruleSpecs, err := m.nfdController.ruleLister.List(k8sLabels.Everything())
-	sort.Slice(ruleSpecs, func(i, j int) bool {
-		return ruleSpecs[i].Name < ruleSpecs[j].Name
-	})
-
+	processStart := time.Now()
+	eval, err := m.evaluateNodeFeatureRules(nodeName, features, false)
 	if err != nil {
-		klog.ErrorS(err, "failed to list NodeFeatureRule resources")
+		klog.ErrorS(err, "failed to evaluate NodeFeatureRule objects", "nodeName", nodeName)
 		return nil, nil, nil, nil
 	}
+	nfrProcessingErrors.Add(float64(eval.errors))
+	m.reportRuleConflicts(nodeName, eval.output.Conflicts, eval.rules)
+	m.nfdController.ruleMatches.set(nodeName, eval.matchedRules)
+
+	processingTime := time.Since(processStart)
+	klog.V(2).InfoS("processed NodeFeatureRule objects", "nodeName", nodeName, "objectCount", len(eval.rules), "duration", processingTime)
+
+	return eval.output.Labels, eval.output.Annotations, eval.output.ExtendedResources, eval.output.Taints
+}
+
+// nodeFeatureRuleEvaluation is the result of evaluating the NodeFeatureRule
+// objects against the features of one node.
+type nodeFeatureRuleEvaluation struct {
+	// rules are the evaluated objects, in evaluation order.
+	rules []*compiledRuleCacheEntry
+	// output is the merged node output of all rules.
+	output *nodefeaturerule.OutputMerger
+	// matchedRules are the names of the objects with at least one matching
+	// rule.
+	matchedRules map[string]struct{}
+	// errors is the number of errors encountered.
+	errors int
+	// traces are the evaluation traces of the objects, only recorded if
+	// requested.
+	traces []nodeFeatureRuleTrace
+}
+
+// evaluateNodeFeatureRules evaluates the NodeFeatureRule objects against the
+// features of a node, in evaluation order. The output of each rule is fed
+// back to the features for subsequent rules to match. If trace is true, a
+// trace of the evaluation of each object is recorded, too.
+func (m *nfdMaster) evaluateNodeFeatureRules(nodeName string, features *nfdv1alpha1.Features, trace bool) (*nodeFeatureRuleEvaluation, error) {
+	ruleSpecs, err := m.nfdController.ruleLister.List(k8sLabels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list NodeFeatureRule resources: %w", err)
+	}
+
+	eval := &nodeFeatureRuleEvaluation{
+		rules:        m.nfdController.ruleCache.get(ruleSpecs),
+		output:       nodefeaturerule.NewOutputMerger(),
+		matchedRules: make(map[string]struct{}),
+	}
+
+	for _, c := range eval.rules {
+		spec := c.nfr
+		nfrTrace := nodeFeatureRuleTrace{NodeFeatureRule: spec.Name}
+
+		if c.hasNodeSelector() {
+			matched := false
+			n, err := m.nfdController.getNode(nodeName)
+			if err == nil {
+				matched, err = c.matchesNode(n.Labels)
+			}
+			if err != nil {
+				klog.ErrorS(err, "failed to evaluate nodeSelector of NodeFeatureRule", "nodefeaturerule", klog.KObj(spec), "nodeName", nodeName)
+				eval.errors++
+				nfrTrace.Skipped = err.Error()
+			} else if !matched {
+				klog.V(2).InfoS("nodeSelector of NodeFeatureRule does not match the node, skipping", "nodefeaturerule", klog.KObj(spec), "nodeName", nodeName)
+				nfrTrace.Skipped = "nodeSelector does not match the node"
+			}
+		}
+		if nfrTrace.Skipped != "" {
+			if trace {
+				eval.traces = append(eval.traces, nfrTrace)
+			}
+			continue
+		}
 
-	// Process all rule CRs
-	processStart := time.Now()
-	for _, spec := range ruleSpecs {
 		t := time.Now()
 		switch {
 		case klog.V(3).Enabled():
@@ -988,37 +1087,103 @@ func (m *nfdMaster) processNodeFeatureRule(nodeName string, features *nfdv1alpha
 		case klog.V(1).Enabled():
 			klog.InfoS("executing NodeFeatureRule", "nodefeaturerule", klog.KObj(spec), "nodeName", nodeName)
 		}
-		for _, rule := range spec.Spec.Rules {
-			ruleOut, err := nodefeaturerule.Execute(&rule, features)
+		for _, rule := range c.rules {
+			var ruleOut nodefeaturerule.RuleOutput
+			var err error
+			if trace {
+				var ruleTrace *nodefeaturerule.RuleTrace
+				ruleOut, ruleTrace, err = rule.ExecuteWithTrace(features)
+				nfrTrace.Rules = append(nfrTrace.Rules, ruleTrace)
+			} else {
+				ruleOut, err = rule.Execute(features)
+			}
 			if err != nil {
-				klog.ErrorS(err, "failed to process rule", "ruleName", rule.Name, "nodefeaturerule", klog.KObj(spec), "nodeName", nodeName)
-				nfrProcessingErrors.Inc()
+				klog.ErrorS(err, "failed to process rule", "ruleName", rule.Rule().Name, "nodefeaturerule", klog.KObj(spec), "nodeName", nodeName)
+				eval.errors++
 				continue
 			}
-			taints = append(taints, ruleOut.Taints...)
-
-			l := ruleOut.Labels
-			e := ruleOut.ExtendedResources
-			a := ruleOut.Annotations
-			if m.config.AutoDefaultNs {
-				l = addNsToMapKeys(ruleOut.Labels, nfdv1alpha1.FeatureLabelNs)
-				e = addNsToMapKeys(ruleOut.ExtendedResources, nfdv1alpha1.ExtendedResourceNs)
-				a = addNsToMapKeys(ruleOut.Annotations, nfdv1alpha1.FeatureAnnotationNs)
+			if ruleOut.Matched {
+				eval.matchedRules[spec.Name] = struct{}{}
 			}
-			maps.Copy(labels, l)
-			maps.Copy(extendedResources, e)
-			maps.Copy(annotations, a)
 
-			// Feed back rule output to features map for subsequent rules to match
-			features.InsertAttributeFeatures(nfdv1alpha1.RuleBackrefDomain, nfdv1alpha1.RuleBackrefFeature, ruleOut.Labels)
-			features.InsertAttributeFeatures(nfdv1alpha1.RuleBackrefDomain, nfdv1alpha1.RuleBackrefFeature, ruleOut.Vars)
+			src := nodefeaturerule.RuleSource{NodeFeatureRule: spec.Name, Rule: rule.Rule().Name, Priority: rule.Rule().Priority}
+			conflicts := eval.output.Add(src, m.namespacedRuleOutput(ruleOut))
+
+			// Feed back rule output to features map for subsequent rules to
+			// match, leaving out labels overridden by higher priority rules
+			nodefeaturerule.InsertBackrefs(features, spec.Name, nodefeaturerule.WithoutLostLabels(ruleOut, src, conflicts, m.namespacedLabelKey))
+		}
+		if trace {
+			eval.traces = append(eval.traces, nfrTrace)
+		} else {
+			nfrProcessingTime.WithLabelValues(spec.Name, nodeName).Observe(time.Since(t).Seconds())
+		}
+	}
+	return eval, nil
+}
+
+// namespacedRuleOutput returns the node output of a rule, with the default
+// namespaces added to unprefixed names if AutoDefaultNs is enabled.
+func (m *nfdMaster) namespacedRuleOutput(ruleOut nodefeaturerule.RuleOutput) nodefeaturerule.RuleOutput {
+	if m.config.AutoDefaultNs {
+		return nodefeaturerule.WithDefaultNs(ruleOut)
+	}
+	return ruleOut
+}
+
+// namespacedLabelKey returns the name of a label output by a rule, with the
+// default namespace added if AutoDefaultNs is enabled.
+func (m *nfdMaster) namespacedLabelKey(key string) string {
+	if m.config.AutoDefaultNs {
+		return nodefeaturerule.DefaultNsLabelKey(key)
+	}
+	return key
+}
+
+// reportRuleConflicts reports conflicting output of NodeFeatureRule objects.
+// Conflicts not resolved by rule priorities are also recorded as warning
+// events of the NodeFeatureRule objects involved. Events and logs are only
+// emitted for the first node with the conflict, per generation of the
+// objects, to avoid flooding on every update of every node.
+func (m *nfdMaster) reportRuleConflicts(nodeName string, conflicts []nodefeaturerule.Conflict, compiledRules []*compiledRuleCacheEntry) {
+	// Number of conflicts on the node, by type and resolution
+	counts := make(map[nodefeaturerule.OutputType]map[string]int)
+	for _, t := range []nodefeaturerule.OutputType{nodefeaturerule.OutputTypeLabel, nodefeaturerule.OutputTypeAnnotation, nodefeaturerule.OutputTypeExtendedResource, nodefeaturerule.OutputTypeTaint} {
+		counts[t] = map[string]int{"priority": 0, "order": 0}
+	}
+
+	for _, c := range conflicts {
+		resolution := "order"
+		if c.ResolvedByPriority() {
+			resolution = "priority"
+		}
+		counts[c.Type][resolution]++
+
+		if c.ResolvedByPriority() {
+			klog.V(2).InfoS("conflicting NodeFeatureRule output resolved by priority", "nodeName", nodeName, "conflict", c.String())
+			continue
+		}
+
+		for _, e := range compiledRules {
+			if e.nfr.Name != c.Winner.NodeFeatureRule && e.nfr.Name != c.Loser.NodeFeatureRule {
+				continue
+			}
+			if !e.markConflictReported(c) {
+				klog.V(2).InfoS("conflicting NodeFeatureRule output", "nodeName", nodeName, "conflict", c.String())
+				continue
+			}
+			klog.InfoS("conflicting NodeFeatureRule output, set rule priorities to resolve", "nodefeaturerule", klog.KObj(e.nfr), "nodeName", nodeName, "conflict", c.String())
+			if m.eventRecorder != nil {
+				m.eventRecorder.Eventf(e.nfr, corev1.EventTypeWarning, "OutputConflict", "conflicting output, first detected on node %s: %s", nodeName, c)
+			}
 		}
-		nfrProcessingTime.WithLabelValues(spec.Name, nodeName).Observe(time.Since(t).Seconds())
 	}
-	processingTime := time.Since(processStart)
-	klog.V(2).InfoS("processed NodeFeatureRule objects", "nodeName", nodeName, "objectCount", len(ruleSpecs), "duration", processingTime)
 
-	return labels, annotations, extendedResources, taints
+	for t, byResolution := range counts {
+		for resolution, n := range byResolution {
+			nfrConflicts.WithLabelValues(nodeName, string(t), resolution).Set(float64(n))
+		}
+	}
 }
 
 // updateNodeObject ensures the Kubernetes node object is up to date,
@@ -1339,7 +1504,7 @@ func (m *nfdMaster) startNfdApiController() error {
 		return err
 	}
 	klog.InfoS("starting the nfd api controller")
-	m.nfdController, err = newNfdController(kubeconfig, nfdApiControllerOptions{
+	m.nfdController, err = newNfdController(kubeconfig, m.k8sClient, nfdApiControllerOptions{
 		DisableNodeFeature: !m.args.EnableNodeFeatureApi,
 		ResyncPeriod:       m.config.ResyncPeriod.Duration,
 	})
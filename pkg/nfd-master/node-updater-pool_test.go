@@ -71,7 +71,7 @@ func TestNodeUpdaterStop(t *testing.T) {
 
 func TestRunNodeUpdater(t *testing.T) {
 	fakeMaster := newFakeMaster(fakek8sclient.NewSimpleClientset())
-	fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset())
+	fakeMaster.nfdController = newFakeNfdAPIController(fakenfdclient.NewSimpleClientset(), fakeMaster.k8sClient)
 	nodeUpdaterPool := newFakeNodeUpdaterPool(fakeMaster)
 
 	nodeUpdaterPool.start(10)
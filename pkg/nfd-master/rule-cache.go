@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfdmaster
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
+)
+
+// compiledRuleCache caches the compiled rules of NodeFeatureRule objects so
+// that regexps, integers, templates of the rules and the node selector are
+// parsed only once per generation of the object instead of once per node. The
+// evaluation order of the objects is cached, too.
+type compiledRuleCache struct {
+	sync.Mutex
+	entries map[string]*compiledRuleCacheEntry
+	order   []*compiledRuleCacheEntry
+}
+
+type compiledRuleCacheEntry struct {
+	nfr   *nfdv1alpha1.NodeFeatureRule
+	rules []*nodefeaturerule.CompiledRule
+	// selector is the node selector of the object, nil if the object applies
+	// to all nodes.
+	selector    k8sLabels.Selector
+	selectorErr error
+
+	// reportedConflicts are the conflicts with other rules already reported
+	// for this generation of the object.
+	reportedConflictsMutex sync.Mutex
+	reportedConflicts      map[string]struct{}
+}
+
+func newCompiledRuleCache() *compiledRuleCache {
+	return &compiledRuleCache{entries: make(map[string]*compiledRuleCacheEntry)}
+}
+
+// newCompiledRuleCacheEntry compiles the rules and the node selector of a
+// NodeFeatureRule object.
+func newCompiledRuleCacheEntry(nfr *nfdv1alpha1.NodeFeatureRule) *compiledRuleCacheEntry {
+	e := &compiledRuleCacheEntry{nfr: nfr, rules: make([]*nodefeaturerule.CompiledRule, len(nfr.Spec.Rules))}
+	for i := range nfr.Spec.Rules {
+		e.rules[i] = nodefeaturerule.CompileRule(&nfr.Spec.Rules[i])
+	}
+	if nfr.Spec.NodeSelector != nil {
+		e.selector, e.selectorErr = metav1.LabelSelectorAsSelector(nfr.Spec.NodeSelector)
+	}
+	return e
+}
+
+// hasNodeSelector returns true if the object is restricted to a subset of
+// nodes.
+func (e *compiledRuleCacheEntry) hasNodeSelector() bool {
+	return e.nfr.Spec.NodeSelector != nil
+}
+
+// matchesNode returns true if the node selector of the object matches the
+// labels of a node. An error is returned if the node selector is invalid.
+func (e *compiledRuleCacheEntry) matchesNode(nodeLabels map[string]string) (bool, error) {
+	if e.selectorErr != nil {
+		return false, fmt.Errorf("invalid nodeSelector: %w", e.selectorErr)
+	}
+	return e.selector == nil || e.selector.Matches(k8sLabels.Set(nodeLabels)), nil
+}
+
+// markConflictReported records a conflict as reported. It returns false if
+// a conflict of the same output between the same rules was already reported.
+func (e *compiledRuleCacheEntry) markConflictReported(c nodefeaturerule.Conflict) bool {
+	key := fmt.Sprintf("%s/%s/%s/%s", c.Type, c.Key, c.Winner, c.Loser)
+
+	e.reportedConflictsMutex.Lock()
+	defer e.reportedConflictsMutex.Unlock()
+	if _, ok := e.reportedConflicts[key]; ok {
+		return false
+	}
+	if e.reportedConflicts == nil {
+		e.reportedConflicts = make(map[string]struct{})
+	}
+	e.reportedConflicts[key] = struct{}{}
+	return true
+}
+
+// valid returns true if the cache entry was compiled from the given object.
+// Objects from the informer cache are never modified in place so the same
+// pointer always means the same content. Otherwise, the generation of the
+// object is used to detect changes in the spec.
+func (e *compiledRuleCacheEntry) valid(nfr *nfdv1alpha1.NodeFeatureRule) bool {
+	if e.nfr == nfr {
+		return true
+	}
+	return e.nfr.UID == nfr.UID && nfr.Generation != 0 && e.nfr.Generation == nfr.Generation
+}
+
+// get returns the compiled rules of the given NodeFeatureRule objects in
+// evaluation order, compiling the objects that are not in the cache or have
+// changed. Cached objects that are not in the list are dropped from the cache.
+func (c *compiledRuleCache) get(nfrs []*nfdv1alpha1.NodeFeatureRule) []*compiledRuleCacheEntry {
+	c.Lock()
+	defer c.Unlock()
+
+	changed := false
+	seen := make(map[string]struct{}, len(nfrs))
+	for _, nfr := range nfrs {
+		seen[nfr.Name] = struct{}{}
+
+		e, ok := c.entries[nfr.Name]
+		if !ok || !e.valid(nfr) {
+			klog.V(4).InfoS("compiling NodeFeatureRule", "nodefeaturerule", klog.KObj(nfr), "generation", nfr.Generation)
+			e = newCompiledRuleCacheEntry(nfr)
+			c.entries[nfr.Name] = e
+			changed = true
+		}
+	}
+
+	if len(c.entries) > len(seen) {
+		for name := range c.entries {
+			if _, ok := seen[name]; !ok {
+				delete(c.entries, name)
+			}
+		}
+		changed = true
+	}
+
+	if changed || c.order == nil {
+		c.updateOrder()
+	}
+	return c.order
+}
+
+// updateOrder updates the evaluation order of the cached objects.
+func (c *compiledRuleCache) updateOrder() {
+	nfrs := make([]*nfdv1alpha1.NodeFeatureRule, 0, len(c.entries))
+	for _, e := range c.entries {
+		nfrs = append(nfrs, e.nfr)
+	}
+	sorted, err := nodefeaturerule.SortNodeFeatureRules(nfrs)
+	if err != nil {
+		klog.ErrorS(err, "failed to determine the evaluation order of NodeFeatureRule objects, falling back to alphabetical order for the rest")
+		nfrProcessingErrors.Inc()
+	}
+
+	c.order = make([]*compiledRuleCacheEntry, len(sorted))
+	for i, nfr := range sorted {
+		c.order[i] = c.entries[nfr.Name]
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfdmaster
+
+import (
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
+)
+
+// ruleMatchState tracks which NodeFeatureRule objects matched each node in
+// the last processing of the node.
+type ruleMatchState struct {
+	sync.Mutex
+	nodes map[string]map[string]struct{}
+}
+
+func newRuleMatchState() *ruleMatchState {
+	return &ruleMatchState{nodes: make(map[string]map[string]struct{})}
+}
+
+// set stores the names of the NodeFeatureRule objects that matched a node.
+func (s *ruleMatchState) set(nodeName string, nfrNames map[string]struct{}) {
+	s.Lock()
+	defer s.Unlock()
+	s.nodes[nodeName] = nfrNames
+}
+
+// get returns true if the NodeFeatureRule object matched the node. The second
+// return value is false if the node has not been processed.
+func (s *ruleMatchState) get(nodeName, nfrName string) (matched, known bool) {
+	s.Lock()
+	defer s.Unlock()
+	nfrNames, known := s.nodes[nodeName]
+	_, matched = nfrNames[nfrName]
+	return matched, known
+}
+
+// retain drops the state of all nodes not in the given list.
+func (s *ruleMatchState) retain(nodes []corev1.Node) {
+	names := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		names[n.Name] = struct{}{}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	for nodeName := range s.nodes {
+		if _, ok := names[nodeName]; !ok {
+			delete(s.nodes, nodeName)
+		}
+	}
+}
+
+// nfdAPIUpdateNodesForRules queues an update of the nodes whose output may be
+// affected by the given NodeFeatureRule changes. All nodes are updated if the
+// affected nodes cannot be determined.
+func (m *nfdMaster) nfdAPIUpdateNodesForRules(updates []nodeFeatureRuleUpdate) error {
+	for _, u := range updates {
+		if u.new != nil && usesRuleBackrefs(u.new) {
+			klog.V(1).InfoS("NodeFeatureRule uses rule backreferences, unable to determine affected nodes", "nodefeaturerule", klog.KObj(u.new))
+			return m.nfdAPIUpdateAllNodes()
+		}
+	}
+
+	nodes, err := m.getNodes()
+	if err != nil {
+		return err
+	}
+
+	affected := m.nodesAffectedByRuleUpdates(nodes.Items, updates)
+	klog.InfoS("will process nodes affected by NodeFeatureRule changes", "nodeCount", len(affected), "nodeFeatureRuleCount", len(updates))
+
+	for _, nodeName := range affected {
+		m.nodeUpdaterPool.queue.Add(nodeName)
+	}
+	return nil
+}
+
+// nodesAffectedByRuleUpdates returns the names of the nodes whose output may
+// change because of the given NodeFeatureRule changes. A node is affected if
+// it was matched by the old version of a NodeFeatureRule in its last
+// processing, or if the new version applies to the node and matches the
+// current features of the node. Nodes that have not been processed yet are
+// always affected.
+func (m *nfdMaster) nodesAffectedByRuleUpdates(nodes []corev1.Node, updates []nodeFeatureRuleUpdate) []string {
+	compiled := make([]*compiledRuleCacheEntry, len(updates))
+	for i, u := range updates {
+		if u.new != nil {
+			compiled[i] = newCompiledRuleCacheEntry(u.new)
+		}
+	}
+
+	ret := []string{}
+	for i := range nodes {
+		if m.nodeAffectedByRuleUpdates(&nodes[i], updates, compiled) {
+			ret = append(ret, nodes[i].Name)
+		}
+	}
+	return ret
+}
+
+func (m *nfdMaster) nodeAffectedByRuleUpdates(node *corev1.Node, updates []nodeFeatureRuleUpdate, compiled []*compiledRuleCacheEntry) bool {
+	nodeName := node.Name
+	for _, u := range updates {
+		var name string
+		if u.old != nil {
+			name = u.old.Name
+		} else {
+			name = u.new.Name
+		}
+		if matched, known := m.nfdController.ruleMatches.get(nodeName, name); !known || matched {
+			return true
+		}
+	}
+
+	var features *nfdv1alpha1.NodeFeatureSpec
+	for i, c := range compiled {
+		if c == nil || len(c.rules) == 0 {
+			continue
+		}
+		if matched, err := c.matchesNode(node.Labels); err == nil && !matched {
+			continue
+		}
+		if features == nil {
+			var err error
+			if features, err = m.getAndMergeNodeFeatures(nodeName); err != nil {
+				klog.ErrorS(err, "failed to get features of node", "nodeName", nodeName)
+				return true
+			}
+		}
+		if matchesNodeFeatureRule(c.rules, &features.Features) {
+			klog.V(2).InfoS("node affected by NodeFeatureRule change", "nodeName", nodeName, "nodefeaturerule", klog.KObj(updates[i].new))
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeFeatureRule returns true if any of the rules of a
+// NodeFeatureRule object matches the features.
+func matchesNodeFeatureRule(rules []*nodefeaturerule.CompiledRule, features *nfdv1alpha1.Features) bool {
+	for _, rule := range rules {
+		if ruleOut, err := rule.Execute(features); err == nil && ruleOut.Matched {
+			return true
+		}
+	}
+	return false
+}
+
+// usesRuleBackrefs returns true if any rule of the NodeFeatureRule object
+// matches against the output of other rules, i.e. features of the "rule"
+// domain. Whether such rules match depends on the output of other
+// NodeFeatureRule objects.
+func usesRuleBackrefs(nfr *nfdv1alpha1.NodeFeatureRule) bool {
+	isBackref := func(m nfdv1alpha1.FeatureMatcher) bool {
+		for _, term := range m {
+			if strings.HasPrefix(strings.ToLower(term.Feature), nfdv1alpha1.RuleBackrefDomain+".") {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range nfr.Spec.Rules {
+		if isBackref(r.MatchFeatures) {
+			return true
+		}
+		for _, e := range r.MatchAny {
+			if isBackref(e.MatchFeatures) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -399,3 +399,76 @@ func TestAdvertiseFeatureLabels(t *testing.T) {
 		})
 	})
 }
+
+// countingSource is a feature source counting its Discover() calls
+type countingSource struct {
+	name      string
+	discovers int
+}
+
+func (s *countingSource) Name() string { return s.name }
+
+func (s *countingSource) Discover() error { s.discovers++; return nil }
+
+func (s *countingSource) GetFeatures() *nfdv1alpha1.Features { return nfdv1alpha1.NewFeatures() }
+
+func TestFeatureSourceIntervals(t *testing.T) {
+	Convey("When running feature discovery with per-source intervals", t, func() {
+		w, err := NewNfdWorker(&Args{})
+		So(err, ShouldBeNil)
+		worker := w.(*nfdWorker)
+
+		worker.config = newDefaultConfig()
+		worker.config.Core.NoPublish = true
+		worker.config.Core.SleepInterval = utils.DurationVal{Duration: 10 * time.Second}
+		worker.config.Core.FeatureSourceIntervals = map[string]utils.DurationVal{
+			"slow": {Duration: time.Hour},
+			"once": {Duration: 0},
+			"fast": {Duration: 2 * time.Second},
+		}
+		fast := &countingSource{name: "fast"}
+		def := &countingSource{name: "default"}
+		once := &countingSource{name: "once"}
+		slow := &countingSource{name: "slow"}
+		worker.featureSources = []source.FeatureSource{def, fast, once, slow}
+
+		So(worker.discoveryInterval(), ShouldEqual, 2*time.Second)
+
+		Convey("all sources should be discovered in the first pass", func() {
+			So(worker.runFeatureDiscovery(nil), ShouldBeNil)
+			So([]int{def.discovers, fast.discovers, once.discovers, slow.discovers}, ShouldResemble, []int{1, 1, 1, 1})
+
+			Convey("only sources that are due should be re-discovered", func() {
+				for name := range worker.lastDiscovery {
+					worker.lastDiscovery[name] = worker.lastDiscovery[name].Add(-11 * time.Second)
+				}
+				So(worker.runFeatureDiscovery(nil), ShouldBeNil)
+				So([]int{def.discovers, fast.discovers, once.discovers, slow.discovers}, ShouldResemble, []int{2, 2, 1, 1})
+
+				So(worker.runFeatureDiscovery(nil), ShouldBeNil)
+				So([]int{def.discovers, fast.discovers, once.discovers, slow.discovers}, ShouldResemble, []int{2, 2, 1, 1})
+			})
+
+			Convey("forced re-discovery should override the intervals", func() {
+				So(worker.runFeatureDiscovery(map[string]struct{}{"slow": {}}), ShouldBeNil)
+				So([]int{def.discovers, fast.discovers, once.discovers, slow.discovers}, ShouldResemble, []int{1, 1, 1, 2})
+
+				So(worker.runFeatureDiscovery(map[string]struct{}{"all": {}}), ShouldBeNil)
+				So([]int{def.discovers, fast.discovers, once.discovers, slow.discovers}, ShouldResemble, []int{2, 2, 2, 3})
+			})
+		})
+
+		Convey("refresh requests should be validated and coalesced", func() {
+			So(worker.Refresh("non-existent"), ShouldNotBeNil)
+			// Registered but not enabled
+			So(worker.Refresh("cpu"), ShouldNotBeNil)
+			So(worker.Refresh("fast"), ShouldBeNil)
+			So(worker.Refresh("slow"), ShouldBeNil)
+			So(len(worker.refresh), ShouldEqual, 1)
+			So(worker.takeRefreshRequests(), ShouldResemble, map[string]struct{}{"fast": {}, "slow": {}})
+
+			So(worker.Refresh(), ShouldBeNil)
+			So(worker.takeRefreshRequests(), ShouldResemble, map[string]struct{}{"all": {}})
+		})
+	})
+}
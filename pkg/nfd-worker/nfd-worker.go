@@ -24,8 +24,10 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/exp/maps"
@@ -46,20 +48,26 @@ import (
 	nfdclient "sigs.k8s.io/node-feature-discovery/pkg/generated/clientset/versioned"
 	pb "sigs.k8s.io/node-feature-discovery/pkg/labeler"
 	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 	"sigs.k8s.io/node-feature-discovery/pkg/version"
 	"sigs.k8s.io/node-feature-discovery/source"
 
 	// Register all source packages
+	_ "sigs.k8s.io/node-feature-discovery/source/accelerator"
 	_ "sigs.k8s.io/node-feature-discovery/source/cpu"
 	_ "sigs.k8s.io/node-feature-discovery/source/custom"
+	_ "sigs.k8s.io/node-feature-discovery/source/deviceplugin"
 	_ "sigs.k8s.io/node-feature-discovery/source/fake"
 	_ "sigs.k8s.io/node-feature-discovery/source/kernel"
 	_ "sigs.k8s.io/node-feature-discovery/source/local"
 	_ "sigs.k8s.io/node-feature-discovery/source/memory"
 	_ "sigs.k8s.io/node-feature-discovery/source/network"
 	_ "sigs.k8s.io/node-feature-discovery/source/pci"
+	_ "sigs.k8s.io/node-feature-discovery/source/power"
+	_ "sigs.k8s.io/node-feature-discovery/source/rdma"
 	_ "sigs.k8s.io/node-feature-discovery/source/storage"
 	_ "sigs.k8s.io/node-feature-discovery/source/system"
+	_ "sigs.k8s.io/node-feature-discovery/source/time"
 	_ "sigs.k8s.io/node-feature-discovery/source/usb"
 )
 
@@ -67,6 +75,7 @@ import (
 type NfdWorker interface {
 	Run() error
 	Stop()
+	Refresh(sources ...string) error
 }
 
 // NFDConfig contains the configuration settings of NfdWorker.
@@ -83,6 +92,8 @@ type coreConfig struct {
 	Sources        *[]string
 	LabelSources   []string
 	SleepInterval  utils.DurationVal
+
+	FeatureSourceIntervals map[string]utils.DurationVal
 }
 
 type sourcesConfig map[string]source.Config
@@ -96,6 +107,7 @@ type Args struct {
 	CertFile             string
 	ConfigFile           string
 	EnableNodeFeatureApi bool
+	HostRoot             string
 	KeyFile              string
 	Klog                 map[string]*utils.KlogFlagVal
 	Kubeconfig           string
@@ -126,8 +138,12 @@ type nfdWorker struct {
 	grpcClient          pb.LabelerClient
 	nfdClient           *nfdclient.Clientset
 	stop                chan struct{} // channel for signaling stop
+	refresh             chan struct{} // channel for signaling forced rediscovery
+	refreshMutex        sync.Mutex
+	refreshPending      map[string]struct{}
 	featureSources      []source.FeatureSource
 	labelSources        []source.LabelSource
+	lastDiscovery       map[string]time.Time
 }
 
 // This ticker can represent infinite and normal intervals.
@@ -142,6 +158,9 @@ func NewNfdWorker(args *Args) (NfdWorker, error) {
 		config:              &NFDConfig{},
 		kubernetesNamespace: utils.GetKubernetesNamespace(),
 		stop:                make(chan struct{}, 1),
+		refresh:             make(chan struct{}, 1),
+		refreshPending:      make(map[string]struct{}),
+		lastDiscovery:       make(map[string]time.Time),
 	}
 
 	// Check TLS related args
@@ -161,6 +180,10 @@ func NewNfdWorker(args *Args) (NfdWorker, error) {
 		nfd.configFilePath = filepath.Clean(args.ConfigFile)
 	}
 
+	if args.HostRoot != "" {
+		hostpath.SetHostRoot(args.HostRoot)
+	}
+
 	return nfd, nil
 }
 
@@ -187,22 +210,73 @@ func (i *infiniteTicker) Reset(d time.Duration) {
 	}
 }
 
+// discoveryInterval returns the interval of the discovery loop, i.e. the
+// shortest of the sleep interval and the per-source intervals of the enabled
+// feature sources. A non-positive value means that no periodic discovery is
+// done.
+func (w *nfdWorker) discoveryInterval() time.Duration {
+	interval := w.config.Core.SleepInterval.Duration
+	for _, s := range w.featureSources {
+		if d, ok := w.config.Core.FeatureSourceIntervals[s.Name()]; ok && d.Duration > 0 {
+			if interval <= 0 || d.Duration < interval {
+				interval = d.Duration
+			}
+		}
+	}
+	return interval
+}
+
+// sourceInterval returns the discovery interval of a feature source.
+func (w *nfdWorker) sourceInterval(name string) time.Duration {
+	if d, ok := w.config.Core.FeatureSourceIntervals[name]; ok {
+		return d.Duration
+	}
+	return w.config.Core.SleepInterval.Duration
+}
+
+// discoveryDue returns true if the feature source needs to be (re-)discovered
+// at the given time.
+func (w *nfdWorker) discoveryDue(name string, now time.Time, forced map[string]struct{}) bool {
+	if _, ok := forced["all"]; ok {
+		return true
+	}
+	if _, ok := forced[name]; ok {
+		return true
+	}
+	last, ok := w.lastDiscovery[name]
+	if !ok {
+		return true
+	}
+	interval := w.sourceInterval(name)
+	if interval <= 0 {
+		return false
+	}
+	// Allow some slack so that a source is not skipped because of the
+	// scheduling jitter of the discovery loop.
+	return now.Sub(last) >= interval-w.discoveryInterval()/2
+}
+
 // Run feature discovery.
-func (w *nfdWorker) runFeatureDiscovery() error {
+func (w *nfdWorker) runFeatureDiscovery(forced map[string]struct{}) error {
 	discoveryStart := time.Now()
 	for _, s := range w.featureSources {
+		if !w.discoveryDue(s.Name(), discoveryStart, forced) {
+			klog.V(3).InfoS("feature discovery not due, using cached features", "featureSource", s.Name())
+			continue
+		}
 		currentSourceStart := time.Now()
 		if err := s.Discover(); err != nil {
 			klog.ErrorS(err, "feature discovery failed", "source", s.Name())
 		}
+		w.lastDiscovery[s.Name()] = discoveryStart
 		klog.V(3).InfoS("feature discovery completed", "featureSource", s.Name(), "duration", time.Since(currentSourceStart))
 	}
 
 	discoveryDuration := time.Since(discoveryStart)
 	klog.V(2).InfoS("feature discovery of all sources completed", "duration", discoveryDuration)
 	featureDiscoveryDuration.WithLabelValues(utils.NodeName()).Observe(discoveryDuration.Seconds())
-	if w.config.Core.SleepInterval.Duration > 0 && discoveryDuration > w.config.Core.SleepInterval.Duration/2 {
-		klog.InfoS("feature discovery sources took over half of sleep interval ", "duration", discoveryDuration, "sleepInterval", w.config.Core.SleepInterval.Duration)
+	if interval := w.discoveryInterval(); interval > 0 && discoveryDuration > interval/2 {
+		klog.InfoS("feature discovery sources took over half of sleep interval ", "duration", discoveryDuration, "sleepInterval", interval)
 	}
 	// Get the set of feature labels.
 	labels := createFeatureLabels(w.labelSources, w.config.Core.LabelWhiteList.Regexp)
@@ -215,6 +289,43 @@ func (w *nfdWorker) runFeatureDiscovery() error {
 	return nil
 }
 
+// Refresh requests immediate re-discovery of the named feature sources, or
+// all enabled feature sources if no names are given. An error is returned if
+// any of the named sources is not enabled. Requests are coalesced and handled
+// asynchronously by the main loop of Run.
+func (w *nfdWorker) Refresh(sources ...string) error {
+	w.refreshMutex.Lock()
+	for _, name := range sources {
+		if !slices.ContainsFunc(w.featureSources, func(s source.FeatureSource) bool { return s.Name() == name }) {
+			w.refreshMutex.Unlock()
+			return fmt.Errorf("feature source %q is not enabled", name)
+		}
+	}
+	if len(sources) == 0 {
+		sources = []string{"all"}
+	}
+	for _, name := range sources {
+		w.refreshPending[name] = struct{}{}
+	}
+	w.refreshMutex.Unlock()
+
+	select {
+	case w.refresh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// takeRefreshRequests returns and clears the set of feature sources pending
+// for forced re-discovery.
+func (w *nfdWorker) takeRefreshRequests() map[string]struct{} {
+	w.refreshMutex.Lock()
+	defer w.refreshMutex.Unlock()
+	pending := w.refreshPending
+	w.refreshPending = make(map[string]struct{})
+	return pending
+}
+
 // Run NfdWorker client. Returns if a fatal error is encountered, or, after
 // one request if OneShot is set to 'true' in the worker args.
 func (w *nfdWorker) Run() error {
@@ -239,7 +350,7 @@ func (w *nfdWorker) Run() error {
 
 	// Create ticker for feature discovery and run feature discovery once before the loop.
 	labelTrigger := infiniteTicker{Ticker: time.NewTicker(1)}
-	labelTrigger.Reset(w.config.Core.SleepInterval.Duration)
+	labelTrigger.Reset(w.discoveryInterval())
 	defer labelTrigger.Stop()
 
 	// Register to metrics server
@@ -252,7 +363,7 @@ func (w *nfdWorker) Run() error {
 		defer m.Stop()
 	}
 
-	err = w.runFeatureDiscovery()
+	err = w.runFeatureDiscovery(nil)
 	if err != nil {
 		return err
 	}
@@ -265,7 +376,15 @@ func (w *nfdWorker) Run() error {
 	for {
 		select {
 		case <-labelTrigger.C:
-			err = w.runFeatureDiscovery()
+			err = w.runFeatureDiscovery(nil)
+			if err != nil {
+				return err
+			}
+
+		case <-w.refresh:
+			forced := w.takeRefreshRequests()
+			klog.InfoS("forced re-discovery of feature sources requested", "featureSources", maps.Keys(forced))
+			err = w.runFeatureDiscovery(forced)
 			if err != nil {
 				return err
 			}
@@ -280,10 +399,11 @@ func (w *nfdWorker) Run() error {
 				w.grpcDisconnect()
 			}
 
-			// Always re-label after a re-config event. This way the new config
-			// comes into effect even if the sleep interval is long (or infinite)
-			labelTrigger.Reset(w.config.Core.SleepInterval.Duration)
-			err = w.runFeatureDiscovery()
+			// Always re-discover and re-label after a re-config event. This
+			// way the new config comes into effect even if the sleep interval
+			// is long (or infinite)
+			labelTrigger.Reset(w.discoveryInterval())
+			err = w.runFeatureDiscovery(map[string]struct{}{"all": {}})
 			if err != nil {
 				return err
 			}
@@ -378,6 +498,13 @@ func (c *coreConfig) sanitize() {
 			"sleepInterval", c.SleepInterval.Duration.String())
 		c.SleepInterval = utils.DurationVal{Duration: time.Second}
 	}
+	for name, d := range c.FeatureSourceIntervals {
+		if d.Duration > 0 && d.Duration < time.Second {
+			klog.InfoS("too short feature source interval specified, forcing to 1s",
+				"featureSource", name, "interval", d.Duration.String())
+			c.FeatureSourceIntervals[name] = utils.DurationVal{Duration: time.Second}
+		}
+	}
 }
 
 func (w *nfdWorker) configureCore(c coreConfig) error {
@@ -415,9 +542,11 @@ func (w *nfdWorker) configureCore(c coreConfig) error {
 		}
 	}
 
+	// Refresh() checks the enabled sources from another goroutine
+	w.refreshMutex.Lock()
 	w.featureSources = maps.Values(featureSources)
-
 	sort.Slice(w.featureSources, func(i, j int) bool { return w.featureSources[i].Name() < w.featureSources[j].Name() })
+	w.refreshMutex.Unlock()
 
 	// Determine enabled label sources
 	labelSources := make(map[string]source.LabelSource)
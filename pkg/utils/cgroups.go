@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// cgroupMiscCapacityPaths are the possible locations of the misc.capacity
+// file of the root cgroup, relative to sysfs. The first one is used with
+// cgroup v2 and the second one with cgroup v1.
+var cgroupMiscCapacityPaths = []string{"fs/cgroup/misc.capacity", "fs/cgroup/misc/misc.capacity"}
+
+// GetCgroupMiscCapacity returns the capacity of all resources managed by the
+// misc cgroup controller of the host.
+func GetCgroupMiscCapacity() (map[string]int64, error) {
+	for _, p := range cgroupMiscCapacityPaths {
+		f, err := os.Open(hostpath.SysfsDir.Path(p))
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+
+		return parseCgroupMiscCapacity(f)
+	}
+	return nil, fmt.Errorf("misc cgroup controller not available")
+}
+
+// parseCgroupMiscCapacity parses the content of a misc.capacity file. Each
+// line of the file is of format "<resource> <capacity>". Malformed lines are
+// skipped.
+func parseCgroupMiscCapacity(r io.Reader) (map[string]int64, error) {
+	capacity := make(map[string]int64)
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			klog.V(2).InfoS("skipping invalid capacity of misc cgroup resource", "resource", fields[0], "err", err)
+			continue
+		}
+		capacity[fields[0]] = v
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
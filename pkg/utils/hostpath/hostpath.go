@@ -17,9 +17,15 @@ limitations under the License.
 package hostpath
 
 import (
+	"os"
 	"path/filepath"
+	"strings"
 )
 
+// HostRootEnv is the name of the environment variable that can be used to
+// override the location of the host root filesystem at runtime.
+const HostRootEnv = "NFD_HOST_ROOT"
+
 var (
 	pathPrefix = "/"
 	// BootDir is where the /boot directory of the system to be inspected is located
@@ -34,8 +40,38 @@ var (
 	VarDir = HostDir(pathPrefix + "var")
 	// LibDir is where the /lib directory of the system to be inspected is located
 	LibDir = HostDir(pathPrefix + "lib")
+	// ProcfsDir is where the /proc directory of the system to be inspected is located
+	ProcfsDir = HostDir(pathPrefix + "proc")
+	// RunDir is where the /run directory of the system to be inspected is located
+	RunDir = HostDir(pathPrefix + "run")
 )
 
+func init() {
+	if root, ok := os.LookupEnv(HostRootEnv); ok && root != "" {
+		SetHostRoot(root)
+	}
+}
+
+// SetHostRoot relocates all host directories under the given root directory.
+// For example, with root set to "/host" SysfsDir becomes "/host/sys".
+func SetHostRoot(root string) {
+	setPathPrefix(strings.TrimSuffix(filepath.Clean(root), "/") + "/")
+}
+
+// setPathPrefix re-initializes all host directories using the given path
+// prefix.
+func setPathPrefix(prefix string) {
+	pathPrefix = prefix
+	BootDir = HostDir(pathPrefix + "boot")
+	EtcDir = HostDir(pathPrefix + "etc")
+	SysfsDir = HostDir(pathPrefix + "sys")
+	UsrDir = HostDir(pathPrefix + "usr")
+	VarDir = HostDir(pathPrefix + "var")
+	LibDir = HostDir(pathPrefix + "lib")
+	ProcfsDir = HostDir(pathPrefix + "proc")
+	RunDir = HostDir(pathPrefix + "run")
+}
+
 // HostDir is a helper for handling host system directories
 type HostDir string
 
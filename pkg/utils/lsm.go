@@ -0,0 +1,40 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"os"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// GetActiveLsms returns the set of active Linux Security Modules of the host,
+// from the comma-separated list in /sys/kernel/security/lsm.
+func GetActiveLsms() (map[string]bool, error) {
+	data, err := os.ReadFile(hostpath.SysfsDir.Path("kernel/security/lsm"))
+	if err != nil {
+		return nil, err
+	}
+	lsms := make(map[string]bool)
+	for _, lsm := range strings.Split(strings.TrimSpace(string(data)), ",") {
+		if lsm != "" {
+			lsms[lsm] = true
+		}
+	}
+	return lsms, nil
+}
@@ -32,10 +32,6 @@ import (
 	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 )
 
-var (
-	sysBusNodeBasepath = hostpath.SysfsDir.Path("bus/node/devices")
-)
-
 // NumaMemoryResources contains information of the memory resources per NUMA
 // nodes of the system.
 type NumaMemoryResources map[int]MemoryResourceInfo
@@ -45,6 +41,7 @@ type MemoryResourceInfo map[corev1.ResourceName]int64
 
 // GetNumaMemoryResources returns total amount of memory and hugepages under NUMA nodes
 func GetNumaMemoryResources() (NumaMemoryResources, error) {
+	sysBusNodeBasepath := hostpath.SysfsDir.Path("bus/node/devices")
 	nodes, err := os.ReadDir(sysBusNodeBasepath)
 	if err != nil {
 		return nil, err
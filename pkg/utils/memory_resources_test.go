@@ -23,6 +23,8 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 )
 
 const (
@@ -72,7 +74,11 @@ func TestGetMemoryResourceCounters(t *testing.T) {
 	}
 	defer os.RemoveAll(rootDir) // clean up
 
-	sysBusNodeBasepath = rootDir
+	origSysfsDir := hostpath.SysfsDir
+	hostpath.SysfsDir = hostpath.HostDir(rootDir)
+	defer func() { hostpath.SysfsDir = origSysfsDir }()
+
+	rootDir = filepath.Join(rootDir, "bus/node/devices")
 
 	// set mock hugepages
 	if err := makeHugepagesTree(rootDir, 2); err != nil {
@@ -27,6 +27,7 @@ import (
 
 type MetricsServer struct {
 	srv *http.Server
+	mux *http.ServeMux
 }
 
 // RunMetricsServer starts a new http server to expose metrics.
@@ -36,7 +37,12 @@ func CreateMetricsServer(port int, cs ...prometheus.Collector) *MetricsServer {
 	mux := http.NewServeMux()
 	mux.Handle("/metrics", promhttp.HandlerFor(r, promhttp.HandlerOpts{}))
 
-	return &MetricsServer{srv: &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}}
+	return &MetricsServer{srv: &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: mux}, mux: mux}
+}
+
+// HandleFunc registers an additional handler function for the given pattern.
+func (s *MetricsServer) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, handler)
 }
 
 // Run runs the metrics server.
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSysfsAttr(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "vendor"), []byte("0x8086\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := ReadSysfsAttr(dir, "vendor")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != "0x8086" {
+		t.Errorf("expected %q, got %q", "0x8086", v)
+	}
+
+	if _, err := ReadSysfsAttr(dir, "device"); err == nil {
+		t.Errorf("expected an error for a missing attribute")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Name of this feature source
+const Name = "accelerator"
+
+const (
+	// DeviceFeature exposes GPUs and other accelerator devices
+	DeviceFeature = "device"
+	// CryptoFeature exposes crypto algorithm implementations of the kernel
+	CryptoFeature = "crypto"
+	// IdxdFeature exposes Intel DSA and IAA devices
+	IdxdFeature = "idxd"
+	// QatFeature exposes Intel QAT devices
+	QatFeature = "qat"
+)
+
+// acceleratorSource implements the FeatureSource and LabelSource interfaces.
+type acceleratorSource struct {
+	features *nfdv1alpha1.Features
+}
+
+// Singleton source instance
+var (
+	src acceleratorSource
+	_   source.FeatureSource = &src
+	_   source.LabelSource   = &src
+)
+
+// Name returns an identifier string for this feature source.
+func (s *acceleratorSource) Name() string { return Name }
+
+// Priority method of the LabelSource interface
+func (s *acceleratorSource) Priority() int { return 0 }
+
+// GetLabels method of the LabelSource interface
+func (s *acceleratorSource) GetLabels() (source.FeatureLabels, error) {
+	labels := source.FeatureLabels{}
+	features := s.GetFeatures()
+
+	if len(features.Instances[QatFeature].Elements) > 0 {
+		labels["qat.present"] = true
+	}
+	for _, dev := range features.Instances[IdxdFeature].Elements {
+		switch dev.Attributes["type"] {
+		case "dsa":
+			labels["dsa.present"] = true
+		case "iax":
+			labels["iaa.present"] = true
+		}
+	}
+
+	return labels, nil
+}
+
+// Discover method of the FeatureSource interface
+func (s *acceleratorSource) Discover() error {
+	s.features = nfdv1alpha1.NewFeatures()
+
+	devs, err := detectAccelerators()
+	if err != nil {
+		return fmt.Errorf("failed to detect accelerator devices: %w", err)
+	}
+	s.features.Instances[DeviceFeature] = nfdv1alpha1.NewInstanceFeatures(devs)
+
+	for name, detect := range map[string]func() ([]nfdv1alpha1.InstanceFeature, error){
+		CryptoFeature: detectCrypto,
+		IdxdFeature:   detectIdxd,
+		QatFeature:    detectQat,
+	} {
+		devs, err := detect()
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				klog.ErrorS(err, "failed to detect accelerator feature", "featureName", name)
+			}
+			continue
+		}
+		s.features.Instances[name] = nfdv1alpha1.NewInstanceFeatures(devs)
+	}
+
+	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
+
+	return nil
+}
+
+// GetFeatures method of the FeatureSource Interface.
+func (s *acceleratorSource) GetFeatures() *nfdv1alpha1.Features {
+	if s.features == nil {
+		s.features = nfdv1alpha1.NewFeatures()
+	}
+	return s.features
+}
+
+func init() {
+	source.Register(&src)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
+func TestAcceleratorSource(t *testing.T) {
+	assert.Equal(t, src.Name(), Name)
+
+	// Check that GetLabels works with empty features
+	src.features = nil
+	l, err := src.GetLabels()
+
+	assert.Nil(t, err, err)
+	assert.Empty(t, l)
+
+	expectedFeatures := map[string]*nfdv1alpha1.Features{
+		"rootfs-empty": &nfdv1alpha1.Features{
+			Flags:      map[string]nfdv1alpha1.FlagFeatureSet{},
+			Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{},
+			Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+				DeviceFeature: nfdv1alpha1.InstanceFeatureSet{Elements: []nfdv1alpha1.InstanceFeature{}},
+			},
+		},
+		"rootfs-1": &nfdv1alpha1.Features{
+			Flags:      map[string]nfdv1alpha1.FlagFeatureSet{},
+			Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{},
+			Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+				DeviceFeature: nfdv1alpha1.InstanceFeatureSet{
+					Elements: []nfdv1alpha1.InstanceFeature{
+						{
+							Attributes: map[string]string{
+								"name":        "card0",
+								"class":       "drm",
+								"index":       "0",
+								"driver":      "i915",
+								"pci_address": "0000:00:02.0",
+								"vendor":      "8086",
+								"device":      "9a49",
+								"numa_node":   "-1",
+								"render_node": "true",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":        "card1",
+								"class":       "drm",
+								"index":       "1",
+								"driver":      "amdgpu",
+								"pci_address": "0000:c1:00.0",
+								"vendor":      "1002",
+								"device":      "740f",
+								"numa_node":   "1",
+								"render_node": "true",
+								"memory":      "68702699520",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":        "card2",
+								"class":       "drm",
+								"index":       "2",
+								"driver":      "simple-framebuffer",
+								"render_node": "false",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":        "accel0",
+								"class":       "accel",
+								"index":       "0",
+								"driver":      "intel_vpu",
+								"pci_address": "0000:00:0b.0",
+								"vendor":      "8086",
+								"device":      "7d1d",
+								"numa_node":   "0",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":        "neuron0",
+								"class":       "misc",
+								"index":       "0",
+								"driver":      "neuron",
+								"pci_address": "0000:81:00.0",
+								"vendor":      "1d0f",
+								"device":      "7164",
+								"numa_node":   "1",
+							},
+						},
+					},
+				},
+				CryptoFeature: nfdv1alpha1.InstanceFeatureSet{
+					Elements: []nfdv1alpha1.InstanceFeature{
+						{
+							Attributes: map[string]string{
+								"name":     "crc32c",
+								"driver":   "crc32c-intel",
+								"module":   "kernel",
+								"priority": "200",
+								"type":     "shash",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":     "xts(aes)",
+								"driver":   "xts-aes-aesni",
+								"module":   "aesni_intel",
+								"priority": "401",
+								"type":     "skcipher",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":     "deflate",
+								"driver":   "qat_deflate",
+								"module":   "intel_qat",
+								"priority": "4001",
+								"type":     "acomp",
+							},
+						},
+					},
+				},
+				IdxdFeature: nfdv1alpha1.InstanceFeatureSet{
+					Elements: []nfdv1alpha1.InstanceFeature{
+						{
+							Attributes: map[string]string{
+								"name":                  "dsa0",
+								"type":                  "dsa",
+								"state":                 "enabled",
+								"numa_node":             "0",
+								"max_work_queues":       "8",
+								"max_engines":           "4",
+								"version":               "0x100",
+								"work_queues":           "2",
+								"shared_work_queues":    "1",
+								"dedicated_work_queues": "1",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":                  "iax1",
+								"type":                  "iax",
+								"state":                 "enabled",
+								"numa_node":             "0",
+								"max_work_queues":       "8",
+								"max_engines":           "4",
+								"version":               "0x100",
+								"work_queues":           "1",
+								"shared_work_queues":    "1",
+								"dedicated_work_queues": "0",
+							},
+						},
+					},
+				},
+				QatFeature: nfdv1alpha1.InstanceFeatureSet{
+					Elements: []nfdv1alpha1.InstanceFeature{
+						{
+							Attributes: map[string]string{
+								"pci_address":  "0000:6b:00.0",
+								"device":       "4940",
+								"family":       "4xxx",
+								"vf":           "false",
+								"driver":       "4xxx",
+								"numa_node":    "0",
+								"sriov_numvfs": "16",
+								"state":        "up",
+								"services":     "sym;asym",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"pci_address": "0000:6b:00.1",
+								"device":      "4941",
+								"family":      "4xxx",
+								"vf":          "true",
+								"driver":      "4xxxvf",
+								"numa_node":   "0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	expectedLabels := map[string]source.FeatureLabels{
+		"rootfs-empty": {},
+		"rootfs-1": {
+			"qat.present": true,
+			"dsa.present": true,
+			"iaa.present": true,
+		},
+	}
+
+	for rootfs, expected := range expectedFeatures {
+		t.Run(rootfs, func(t *testing.T) {
+			hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", rootfs))
+			defer hostpath.SetHostRoot("/")
+
+			testSrc := acceleratorSource{}
+			assert.Nil(t, testSrc.Discover())
+			assert.Equal(t, expected, testSrc.GetFeatures())
+
+			l, err := testSrc.GetLabels()
+			assert.Nil(t, err, err)
+			assert.Equal(t, expectedLabels[rootfs], l)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// cryptoAttrs is the list of fields of /proc/crypto entries that we advertise
+var cryptoAttrs = []string{"name", "driver", "module", "priority", "type"}
+
+// softwareCryptoDriverRe matches the drivers of the generic software
+// implementations of crypto algorithms, also when used in templates like
+// "cbc(aes-generic)"
+var softwareCryptoDriverRe = regexp.MustCompile(`-generic\b|-scomp\b|-lib\b|-fixed-time\b|_null\b|\bdrbg_|\bjitterentropy_rng\b`)
+
+// idxdDevRe matches the Intel Data Streaming Accelerator (DSA) and In-Memory
+// Analytics Accelerator (IAA) devices on the dsa bus
+var idxdDevRe = regexp.MustCompile(`^(dsa|iax)(\d+)$`)
+
+// qatDevices contains the PCI device IDs of Intel QuickAssist Technology
+// (QAT) physical and virtual functions, mapped to the device family.
+var qatDevices = map[string]string{
+	"0435": "dh895xcc", "0443": "dh895xcc",
+	"37c8": "c62x", "37c9": "c62x",
+	"19e2": "c3xxx", "19e3": "c3xxx",
+	"18ee": "200xx", "18ef": "200xx",
+	"6f54": "d15xx", "6f55": "d15xx",
+	"4940": "4xxx", "4941": "4xxx",
+	"4942": "401xx", "4943": "401xx",
+	"4944": "402xx", "4945": "402xx",
+	"4946": "420xx", "4947": "420xx",
+}
+
+// qatVFs contains the PCI device IDs of QAT virtual functions
+var qatVFs = map[string]bool{
+	"0443": true, "37c9": true, "19e3": true, "18ef": true, "6f55": true,
+	"4941": true, "4943": true, "4945": true, "4947": true,
+}
+
+// detectCrypto returns the crypto algorithm implementations registered in
+// the kernel that are provided by hardware drivers or architecture specific
+// implementations, built in or loadable modules. Generic software
+// implementations and internal algorithms are omitted.
+func detectCrypto() ([]nfdv1alpha1.InstanceFeature, error) {
+	f, err := os.Open(hostpath.ProcfsDir.Path("crypto"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries, err := parseProcCrypto(f)
+	if err != nil {
+		return nil, err
+	}
+
+	algs := make([]nfdv1alpha1.InstanceFeature, 0)
+	for _, e := range entries {
+		if e["internal"] == "yes" || softwareCryptoDriverRe.MatchString(e["driver"]) {
+			continue
+		}
+		attrs := make(map[string]string, len(cryptoAttrs))
+		for _, k := range cryptoAttrs {
+			if v, ok := e[k]; ok {
+				attrs[k] = v
+			}
+		}
+		algs = append(algs, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return algs, nil
+}
+
+// parseProcCrypto parses the content of /proc/crypto. Entries are separated
+// by an empty line and consist of "<key> : <value>" lines.
+func parseProcCrypto(r io.Reader) ([]map[string]string, error) {
+	var entries []map[string]string
+	var cur map[string]string
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			cur = nil
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid line in /proc/crypto: %q", line)
+		}
+		if cur == nil {
+			cur = make(map[string]string)
+			entries = append(entries, cur)
+		}
+		cur[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// detectIdxd detects the DSA and IAA devices and their work queues.
+func detectIdxd() ([]nfdv1alpha1.InstanceFeature, error) {
+	basePath := hostpath.SysfsDir.Path("bus/dsa/devices")
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	devs := make([]nfdv1alpha1.InstanceFeature, 0)
+	for _, e := range entries {
+		m := idxdDevRe.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		devPath := filepath.Join(basePath, e.Name())
+		attrs := map[string]string{
+			"name": e.Name(),
+			"type": m[1],
+		}
+		for _, attr := range []string{"state", "numa_node", "max_work_queues", "max_engines", "version"} {
+			if v, err := utils.ReadSysfsAttr(devPath, attr); err == nil {
+				attrs[attr] = v
+			}
+		}
+
+		// Work queues of the device are named wq<dev-id>.<wq-id>
+		wqs, _ := filepath.Glob(filepath.Join(basePath, "wq"+m[2]+".*"))
+		var enabled, shared, dedicated int
+		for _, wq := range wqs {
+			if state, err := utils.ReadSysfsAttr(wq, "state"); err != nil || state != "enabled" {
+				continue
+			}
+			enabled++
+			switch mode, _ := utils.ReadSysfsAttr(wq, "mode"); mode {
+			case "shared":
+				shared++
+			case "dedicated":
+				dedicated++
+			}
+		}
+		attrs["work_queues"] = strconv.Itoa(enabled)
+		attrs["shared_work_queues"] = strconv.Itoa(shared)
+		attrs["dedicated_work_queues"] = strconv.Itoa(dedicated)
+
+		devs = append(devs, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return devs, nil
+}
+
+// detectQat detects Intel QAT devices by their PCI device ID.
+func detectQat() ([]nfdv1alpha1.InstanceFeature, error) {
+	basePath := hostpath.SysfsDir.Path("bus/pci/devices")
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	devs := make([]nfdv1alpha1.InstanceFeature, 0)
+	for _, e := range entries {
+		devPath := filepath.Join(basePath, e.Name())
+		if vendor, err := utils.ReadSysfsAttr(devPath, "vendor"); err != nil || vendor != "0x8086" {
+			continue
+		}
+		device, err := utils.ReadSysfsAttr(devPath, "device")
+		if err != nil {
+			continue
+		}
+		device = strings.TrimPrefix(device, "0x")
+		family, ok := qatDevices[device]
+		if !ok {
+			continue
+		}
+
+		attrs := map[string]string{
+			"pci_address": e.Name(),
+			"device":      device,
+			"family":      family,
+			"vf":          strconv.FormatBool(qatVFs[device]),
+		}
+		if driver, err := os.Readlink(filepath.Join(devPath, "driver")); err == nil {
+			attrs["driver"] = filepath.Base(driver)
+		}
+		for _, attr := range []string{"numa_node", "sriov_numvfs"} {
+			if v, err := utils.ReadSysfsAttr(devPath, attr); err == nil {
+				attrs[attr] = v
+			}
+		}
+		// Newer generations expose the state and the enabled services
+		// (e.g. "sym;asym" or "dc") of the device
+		if v, err := utils.ReadSysfsAttr(devPath, "qat/state"); err == nil {
+			attrs["state"] = v
+		}
+		if v, err := utils.ReadSysfsAttr(devPath, "qat/cfg_services"); err == nil {
+			attrs["services"] = v
+		}
+
+		devs = append(devs, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return devs, nil
+}
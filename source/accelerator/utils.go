@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accelerator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// drmCardRe matches the primary nodes of DRM devices, i.e. excluding
+// connectors (e.g. card0-DP-1) and render nodes
+var drmCardRe = regexp.MustCompile(`^card(\d+)$`)
+
+// accelDevRe matches the devices of the compute accelerator subsystem
+var accelDevRe = regexp.MustCompile(`^accel(\d+)$`)
+
+// indexRe matches the numerical suffix of a device name
+var indexRe = regexp.MustCompile(`(\d+)$`)
+
+// acceleratorPciClasses are the PCI classes of devices that are regarded as
+// accelerators when exposed as misc devices: co-processors and processing
+// accelerators.
+var acceleratorPciClasses = []string{"0b40", "12"}
+
+// memoryAttrs are sysfs attributes of the underlying device, relative to the
+// device directory, that hold the size of the device memory in bytes. They
+// are driver specific.
+var memoryAttrs = []string{"mem_info_vram_total"}
+
+// detectAccelerators detects GPUs and other accelerator devices from the
+// drm, accel and misc device classes.
+func detectAccelerators() ([]nfdv1alpha1.InstanceFeature, error) {
+	devs := make([]nfdv1alpha1.InstanceFeature, 0)
+
+	for _, class := range []struct {
+		name   string
+		filter func(string) bool
+	}{
+		{"drm", drmCardRe.MatchString},
+		{"accel", accelDevRe.MatchString},
+		{"misc", isMiscAccelerator},
+	} {
+		classPath := hostpath.SysfsDir.Path("class", class.name)
+		entries, err := os.ReadDir(classPath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list %s devices: %w", class.name, err)
+		}
+		for _, e := range entries {
+			if !class.filter(e.Name()) {
+				continue
+			}
+			devs = append(devs, *readDevInfo(filepath.Join(classPath, e.Name()), class.name))
+		}
+	}
+
+	return devs, nil
+}
+
+// isMiscAccelerator returns true if the misc device is backed by a PCI
+// device of an accelerator class.
+func isMiscAccelerator(name string) bool {
+	devPath := hostpath.SysfsDir.Path("class/misc", name)
+	if !isPciDevice(devPath) {
+		return false
+	}
+	class, err := utils.ReadSysfsAttr(devPath, "device/class")
+	if err != nil {
+		return false
+	}
+	class = strings.TrimPrefix(class, "0x")
+	for _, c := range acceleratorPciClasses {
+		if strings.HasPrefix(class, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// readDevInfo reads the information of one accelerator device.
+func readDevInfo(devPath, class string) *nfdv1alpha1.InstanceFeature {
+	name := filepath.Base(devPath)
+	attrs := map[string]string{
+		"name":  name,
+		"class": class,
+	}
+	if m := indexRe.FindStringSubmatch(name); m != nil {
+		attrs["index"] = m[1]
+	}
+
+	if driver, err := os.Readlink(filepath.Join(devPath, "device/driver")); err == nil {
+		attrs["driver"] = filepath.Base(driver)
+	}
+	if isPciDevice(devPath) {
+		if dev, err := os.Readlink(filepath.Join(devPath, "device")); err == nil {
+			attrs["pci_address"] = filepath.Base(dev)
+		}
+		for _, attr := range []string{"vendor", "device"} {
+			if v, err := utils.ReadSysfsAttr(devPath, "device/"+attr); err == nil {
+				attrs[attr] = strings.TrimPrefix(v, "0x")
+			}
+		}
+	}
+	if v, err := utils.ReadSysfsAttr(devPath, "device/numa_node"); err == nil {
+		attrs["numa_node"] = v
+	}
+
+	if class == "drm" {
+		// Render nodes of the card are listed under the drm directory of the
+		// parent device
+		render := false
+		if nodes, err := os.ReadDir(filepath.Join(devPath, "device/drm")); err == nil {
+			for _, n := range nodes {
+				if strings.HasPrefix(n.Name(), "renderD") {
+					render = true
+					break
+				}
+			}
+		}
+		attrs["render_node"] = strconv.FormatBool(render)
+	}
+
+	for _, attr := range memoryAttrs {
+		if v, err := utils.ReadSysfsAttr(devPath, "device/"+attr); err == nil {
+			attrs["memory"] = v
+			break
+		}
+	}
+
+	return nfdv1alpha1.NewInstanceFeature(attrs)
+}
+
+// isPciDevice returns true if the class device is backed by a PCI device.
+func isPciDevice(devPath string) bool {
+	subsystem, err := os.Readlink(filepath.Join(devPath, "device/subsystem"))
+	return err == nil && filepath.Base(subsystem) == "pci"
+}
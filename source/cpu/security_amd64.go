@@ -20,14 +20,12 @@ limitations under the License.
 package cpu
 
 import (
-	"bufio"
-	"io"
 	"os"
 	"strconv"
-	"strings"
 
 	"github.com/klauspost/cpuid/v2"
 
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
 	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 )
 
@@ -122,47 +120,13 @@ func sevParameterEnabled(parameter string) bool {
 	return false
 }
 
-func retrieveCgroupMiscCapacityValue(miscCgroupPath *os.File, resource string) int64 {
-	var totalResources int64 = -1
-
-	r := bufio.NewReader(miscCgroupPath)
-	for {
-		line, _, err := r.ReadLine()
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return totalResources
-		}
-
-		if !strings.HasPrefix(string(line), resource) {
-			continue
-		}
-
-		s := strings.Split(string(line), " ")
-		resources, err := strconv.ParseInt(s[1], 10, 64)
-		if err != nil {
-			return totalResources
-		}
-
-		totalResources = resources
-		break
-	}
-
-	return totalResources
-}
-
 func getCgroupMiscCapacity(resource string) int64 {
-	miscCgroupsPaths := []string{"fs/cgroup/misc.capacity", "fs/cgroup/misc/misc.capacity"}
-	for _, miscCgroupsPath := range miscCgroupsPaths {
-		miscCgroups := hostpath.SysfsDir.Path(miscCgroupsPath)
-		f, err := os.Open(miscCgroups)
-		if err == nil {
-			defer f.Close()
-
-			return retrieveCgroupMiscCapacityValue(f, resource)
-		}
+	capacity, err := utils.GetCgroupMiscCapacity()
+	if err != nil {
+		return -1
+	}
+	if v, ok := capacity[resource]; ok {
+		return v
 	}
-
 	return -1
 }
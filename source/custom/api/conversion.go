@@ -68,6 +68,7 @@ func convertMatchanyelemToV1alpha1(in *MatchAnyElem, out *nfdv1alpha1.MatchAnyEl
 // convertMatchexpressionToV1alpha1 converts the internal api type to nfdv1alpha1.
 func convertMatchexpressionToV1alpha1(in *MatchExpression, out *nfdv1alpha1.MatchExpression) error {
 	out.Op = nfdv1alpha1.MatchOp(in.Op)
+	out.Type = nfdv1alpha1.MatchValueType(in.Type)
 	if in.Value != nil {
 		in, out := &in.Value, &out.Value
 		*out = make(nfdv1alpha1.MatchValue, len(*in))
@@ -57,6 +57,7 @@ func TestRuleConversion(t *testing.T) {
 						MatchExpressions: &MatchExpressionSet{
 							"attr_1": &MatchExpression{Op: MatchIn, Value: MatchValue{"true"}},
 							"attr_2": &MatchExpression{Op: MatchInRegexp, Value: MatchValue{"^f"}},
+							"attr_3": &MatchExpression{Op: MatchGt, Type: MatchValueTypeQuantity, Value: MatchValue{"1Gi"}},
 						},
 						MatchName: &MatchExpression{Op: MatchIn, Value: MatchValue{"elem-1"}},
 					},
@@ -93,6 +94,7 @@ func TestRuleConversion(t *testing.T) {
 						MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
 							"attr_1": &nfdv1alpha1.MatchExpression{Op: nfdv1alpha1.MatchIn, Value: nfdv1alpha1.MatchValue{"true"}},
 							"attr_2": &nfdv1alpha1.MatchExpression{Op: nfdv1alpha1.MatchInRegexp, Value: nfdv1alpha1.MatchValue{"^f"}},
+							"attr_3": &nfdv1alpha1.MatchExpression{Op: nfdv1alpha1.MatchGt, Type: nfdv1alpha1.MatchValueTypeQuantity, Value: nfdv1alpha1.MatchValue{"1Gi"}},
 						},
 						MatchName: &nfdv1alpha1.MatchExpression{Op: nfdv1alpha1.MatchIn, Value: nfdv1alpha1.MatchValue{"elem-1"}},
 					},
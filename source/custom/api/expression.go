@@ -22,6 +22,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1/nodefeaturerule"
 )
 
 var matchOps = map[MatchOp]struct{}{
@@ -51,6 +54,15 @@ func (m *MatchExpression) Validate() error {
 	if _, ok := matchOps[m.Op]; !ok {
 		return fmt.Errorf("invalid Op %q", m.Op)
 	}
+	switch m.Type {
+	case "":
+	case MatchValueTypeInt, MatchValueTypeFloat, MatchValueTypeQuantity:
+		if m.Op != MatchGt && m.Op != MatchLt && m.Op != MatchGtLt {
+			return fmt.Errorf("type must be empty for Op %q (have %q)", m.Op, m.Type)
+		}
+	default:
+		return fmt.Errorf("invalid Type %q", m.Type)
+	}
 	switch m.Op {
 	case MatchExists, MatchDoesNotExist, MatchIsTrue, MatchIsFalse, MatchAny:
 		if len(m.Value) != 0 {
@@ -60,18 +72,18 @@ func (m *MatchExpression) Validate() error {
 		if len(m.Value) != 1 {
 			return fmt.Errorf("value must contain exactly one element for Op %q (have %v)", m.Op, m.Value)
 		}
-		if _, err := strconv.Atoi(m.Value[0]); err != nil {
-			return fmt.Errorf("value must be an integer for Op %q (have %v)", m.Op, m.Value[0])
+		if _, err := m.parseNumber(m.Value[0]); err != nil {
+			return fmt.Errorf("value must be a number of type %q for Op %q (have %v)", m.valueType(), m.Op, m.Value[0])
 		}
 	case MatchGtLt:
 		if len(m.Value) != 2 {
 			return fmt.Errorf("value must contain exactly two elements for Op %q (have %v)", m.Op, m.Value)
 		}
 		var err error
-		v := make([]int, 2)
+		v := make([]float64, 2)
 		for i := 0; i < 2; i++ {
-			if v[i], err = strconv.Atoi(m.Value[i]); err != nil {
-				return fmt.Errorf("value must contain integers for Op %q (have %v)", m.Op, m.Value)
+			if v[i], err = m.parseNumber(m.Value[i]); err != nil {
+				return fmt.Errorf("value must contain numbers of type %q for Op %q (have %v)", m.valueType(), m.Op, m.Value)
 			}
 		}
 		if v[0] >= v[1] {
@@ -95,6 +107,19 @@ func (m *MatchExpression) Validate() error {
 	return nil
 }
 
+// valueType returns the value type of the expression, defaulting to Int.
+func (m *MatchExpression) valueType() MatchValueType {
+	if m.Type == "" {
+		return MatchValueTypeInt
+	}
+	return m.Type
+}
+
+// parseNumber parses a value of the Gt, Lt and GtLt operators.
+func (m *MatchExpression) parseNumber(v string) (float64, error) {
+	return nodefeaturerule.ParseNumber(nfdv1alpha1.MatchValueType(m.Type), v)
+}
+
 // matchExpression is a helper type for unmarshalling MatchExpression
 type matchExpression MatchExpression
 
@@ -130,6 +155,7 @@ func (m *MatchExpression) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		*m = *newMatchExpression(helper.Op, helper.Value...)
+		m.Type = helper.Type
 	default:
 		return fmt.Errorf("invalid rule '%v' (%T)", v, v)
 	}
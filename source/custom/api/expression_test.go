@@ -31,6 +31,7 @@ func TestMatchExpressionValidate(t *testing.T) {
 	type TC struct {
 		name   string
 		op     MatchOp
+		typ    MatchValueType
 		values V
 		err    ValueAssertionFunc
 	}
@@ -82,11 +83,23 @@ func TestMatchExpressionValidate(t *testing.T) {
 
 		{name: "35", op: MatchIsFalse, err: assert.Nil},
 		{name: "36", op: MatchIsFalse, values: V{"1", "2"}, err: assert.NotNil},
+
+		{name: "37", op: MatchGt, typ: MatchValueTypeInt, values: V{"1"}, err: assert.Nil},
+		{name: "38", op: MatchGt, typ: MatchValueTypeFloat, values: V{"2.5"}, err: assert.Nil},
+		{name: "39", op: MatchLt, typ: MatchValueTypeFloat, values: V{"2Gi"}, err: assert.NotNil},
+		{name: "40", op: MatchLt, typ: MatchValueTypeQuantity, values: V{"2Gi"}, err: assert.Nil},
+		{name: "41", op: MatchGtLt, typ: MatchValueTypeQuantity, values: V{"1.5GHz", "3.2GHz"}, err: assert.Nil},
+		{name: "42", op: MatchGtLt, typ: MatchValueTypeQuantity, values: V{"1Gi", "1G"}, err: assert.NotNil},
+		{name: "43", op: MatchIn, typ: MatchValueTypeFloat, values: V{"1"}, err: assert.NotNil},
+		{name: "44", op: MatchGt, typ: "non-existent-type", values: V{"1"}, err: assert.NotNil},
+		{name: "45", op: MatchIn, typ: MatchValueTypeInt, values: V{"1"}, err: assert.NotNil},
+		{name: "46", op: MatchGt, typ: MatchValueTypeQuantity, values: V{"8KB"}, err: assert.Nil},
+		{name: "47", op: MatchGt, typ: MatchValueTypeQuantity, values: V{"512mb"}, err: assert.NotNil},
 	}
 
 	for _, tc := range tcs {
 		t.Run(tc.name, func(t *testing.T) {
-			me := MatchExpression{Op: tc.op, Value: tc.values}
+			me := MatchExpression{Op: tc.op, Type: tc.typ, Value: tc.values}
 			err := me.Validate()
 			tc.err(t, err)
 		})
@@ -125,17 +138,19 @@ func TestUnmarshalMatchExpressionSet(t *testing.T) {
 "key-6":{"op":"InRegexp","value":["^foo$"]},
 "key-7":{"op":"Lt","value":1},
 "key-8":{"op":"Gt","value":2},
-"key-9":{"op":"GtLt","value":["0","3"]}}`,
+"key-9":{"op":"GtLt","value":["0","3"]},
+"key-10":{"op":"Gt","type":"Quantity","value":"16Gi"}}`,
 			out: MatchExpressionSet{
-				"key-1": &MatchExpression{Op: MatchExists},
-				"key-2": &MatchExpression{Op: MatchDoesNotExist},
-				"key-3": &MatchExpression{Op: MatchIsTrue},
-				"key-4": &MatchExpression{Op: MatchIsFalse},
-				"key-5": &MatchExpression{Op: MatchIn, Value: MatchValue{"str", "true"}},
-				"key-6": &MatchExpression{Op: MatchInRegexp, Value: MatchValue{"^foo$"}},
-				"key-7": &MatchExpression{Op: MatchLt, Value: MatchValue{"1"}},
-				"key-8": &MatchExpression{Op: MatchGt, Value: MatchValue{"2"}},
-				"key-9": &MatchExpression{Op: MatchGtLt, Value: MatchValue{"0", "3"}},
+				"key-1":  &MatchExpression{Op: MatchExists},
+				"key-2":  &MatchExpression{Op: MatchDoesNotExist},
+				"key-3":  &MatchExpression{Op: MatchIsTrue},
+				"key-4":  &MatchExpression{Op: MatchIsFalse},
+				"key-5":  &MatchExpression{Op: MatchIn, Value: MatchValue{"str", "true"}},
+				"key-6":  &MatchExpression{Op: MatchInRegexp, Value: MatchValue{"^foo$"}},
+				"key-7":  &MatchExpression{Op: MatchLt, Value: MatchValue{"1"}},
+				"key-8":  &MatchExpression{Op: MatchGt, Value: MatchValue{"2"}},
+				"key-9":  &MatchExpression{Op: MatchGtLt, Value: MatchValue{"0", "3"}},
+				"key-10": &MatchExpression{Op: MatchGt, Type: MatchValueTypeQuantity, Value: MatchValue{"16Gi"}},
 			},
 			err: assert.Nil,
 		},
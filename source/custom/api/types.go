@@ -93,6 +93,11 @@ type MatchExpression struct {
 	// Op is the operator to be applied.
 	Op MatchOp `json:"op"`
 
+	// Type is the type of the values that the Gt, Lt and GtLt operators
+	// compare: Int (the default), Float or Quantity.
+	// +optional
+	Type MatchValueType `json:"type,omitempty"`
+
 	// Value is the list of values that the operand evaluates the input
 	// against. Value should be empty if the operator is Exists, DoesNotExist,
 	// IsTrue or IsFalse. Value should contain exactly one element if the
@@ -109,6 +114,21 @@ type MatchOp string
 // MatchValue is the list of values associated with a MatchExpression.
 type MatchValue []string
 
+// MatchValueType is the type of the values compared by the numeric operators
+// of a MatchExpression.
+type MatchValueType string
+
+const (
+	// MatchValueTypeInt compares integer numbers. This is the default if the
+	// type is empty.
+	MatchValueTypeInt MatchValueType = "Int"
+	// MatchValueTypeFloat compares decimal numbers.
+	MatchValueTypeFloat MatchValueType = "Float"
+	// MatchValueTypeQuantity compares numbers in the Kubernetes resource
+	// quantity format, with an optional unit.
+	MatchValueTypeQuantity MatchValueType = "Quantity"
+)
+
 const (
 	// MatchAny returns always true.
 	MatchAny MatchOp = ""
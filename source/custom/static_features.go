@@ -27,13 +27,31 @@ func getStaticRules() []nfdv1alpha1.Rule {
 		nfdv1alpha1.Rule{
 			Name:   "RDMA capable static rule",
 			Labels: map[string]string{"rdma.capable": "true"},
-			MatchFeatures: nfdv1alpha1.FeatureMatcher{
-				nfdv1alpha1.FeatureMatcherTerm{
-					Feature: "pci.device",
-					MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
-						"vendor": &nfdv1alpha1.MatchExpression{
-							Op:    nfdv1alpha1.MatchIn,
-							Value: nfdv1alpha1.MatchValue{"15b3"}},
+			// Match any RDMA device, or a Mellanox network adapter whose
+			// RDMA driver is not loaded
+			MatchAny: []nfdv1alpha1.MatchAnyElem{
+				{
+					MatchFeatures: nfdv1alpha1.FeatureMatcher{
+						nfdv1alpha1.FeatureMatcherTerm{
+							Feature: "rdma.device",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+								"name": &nfdv1alpha1.MatchExpression{
+									Op: nfdv1alpha1.MatchExists,
+								},
+							},
+						},
+					},
+				},
+				{
+					MatchFeatures: nfdv1alpha1.FeatureMatcher{
+						nfdv1alpha1.FeatureMatcherTerm{
+							Feature: "pci.device",
+							MatchExpressions: &nfdv1alpha1.MatchExpressionSet{
+								"vendor": &nfdv1alpha1.MatchExpression{
+									Op:    nfdv1alpha1.MatchIn,
+									Value: nfdv1alpha1.MatchValue{"15b3"}},
+							},
+						},
 					},
 				},
 			},
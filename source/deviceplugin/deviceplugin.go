@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deviceplugin
+
+import (
+	"errors"
+	"io/fs"
+
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Name of this feature source
+const Name = "deviceplugin"
+
+const (
+	// ResourceFeature exposes the resources registered by device plugins
+	ResourceFeature = "resource"
+	// RegisteredFeature exposes the names of the resources registered by
+	// device plugins
+	RegisteredFeature = "registered"
+	// CdiFeature exposes the Container Device Interface (CDI) specs
+	CdiFeature = "cdi"
+	// CdiKindFeature exposes the device kinds of the CDI specs
+	CdiKindFeature = "cdikind"
+)
+
+// devicePluginSource implements the FeatureSource interface.
+type devicePluginSource struct {
+	features *nfdv1alpha1.Features
+}
+
+// Singleton source instance
+var (
+	src devicePluginSource
+	_   source.FeatureSource = &src
+)
+
+// Name returns an identifier string for this feature source.
+func (s *devicePluginSource) Name() string { return Name }
+
+// Discover method of the FeatureSource interface
+func (s *devicePluginSource) Discover() error {
+	s.features = nfdv1alpha1.NewFeatures()
+
+	if resources, err := detectResources(); err == nil {
+		s.features.Instances[ResourceFeature] = nfdv1alpha1.NewInstanceFeatures(resources)
+		s.features.Attributes[RegisteredFeature] = nfdv1alpha1.NewAttributeFeatures(sumDevices(resources, "name"))
+	} else if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+		// The kubelet device plugin directory is only readable by root
+		klog.V(2).InfoS("device plugin checkpoint not available", "path", checkpointPath(), "error", err)
+	} else {
+		klog.ErrorS(err, "failed to detect device plugin resources")
+	}
+
+	specs, err := detectCdiSpecs()
+	if err != nil {
+		klog.ErrorS(err, "failed to detect CDI specs")
+	} else {
+		s.features.Instances[CdiFeature] = nfdv1alpha1.NewInstanceFeatures(specs)
+		s.features.Attributes[CdiKindFeature] = nfdv1alpha1.NewAttributeFeatures(sumDevices(specs, "kind"))
+	}
+
+	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
+
+	return nil
+}
+
+// GetFeatures method of the FeatureSource Interface.
+func (s *devicePluginSource) GetFeatures() *nfdv1alpha1.Features {
+	if s.features == nil {
+		s.features = nfdv1alpha1.NewFeatures()
+	}
+	return s.features
+}
+
+func init() {
+	source.Register(&src)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deviceplugin
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
+func TestDevicePluginSource(t *testing.T) {
+	assert.Equal(t, src.Name(), Name)
+
+	expectedFeatures := map[string]*nfdv1alpha1.Features{
+		"rootfs-empty": &nfdv1alpha1.Features{
+			Flags: map[string]nfdv1alpha1.FlagFeatureSet{},
+			Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{
+				CdiKindFeature: nfdv1alpha1.NewAttributeFeatures(map[string]string{}),
+			},
+			Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+				CdiFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{}),
+			},
+		},
+		"rootfs-1": &nfdv1alpha1.Features{
+			Flags: map[string]nfdv1alpha1.FlagFeatureSet{},
+			Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{
+				RegisteredFeature: nfdv1alpha1.NewAttributeFeatures(map[string]string{
+					"intel.com/sriov_netdevice": "4",
+					"nvidia.com/gpu":            "2",
+				}),
+				CdiKindFeature: nfdv1alpha1.NewAttributeFeatures(map[string]string{
+					"nvidia.com/gpu": "3",
+					"intel.com/qat":  "1",
+				}),
+			},
+			Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+				ResourceFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":    "intel.com/sriov_netdevice",
+						"devices": "4",
+					}),
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":    "nvidia.com/gpu",
+						"devices": "2",
+					}),
+				}),
+				CdiFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"kind":        "nvidia.com/gpu",
+						"cdi_version": "0.5.0",
+						"devices":     "3",
+						"spec":        "nvidia.yaml",
+					}),
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"kind":        "intel.com/qat",
+						"cdi_version": "0.6.0",
+						"devices":     "1",
+						"spec":        "intel-qat.json",
+					}),
+				}),
+			},
+		},
+	}
+
+	for rootfs, expected := range expectedFeatures {
+		t.Run(rootfs, func(t *testing.T) {
+			hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", rootfs))
+			defer hostpath.SetHostRoot("/")
+
+			testSrc := devicePluginSource{}
+			assert.Nil(t, testSrc.Discover())
+			assert.Equal(t, expected, testSrc.GetFeatures())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deviceplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// devicePluginsDir is the directory of the kubelet device plugin registry,
+// relative to the host /var directory
+const devicePluginsDir = "lib/kubelet/device-plugins"
+
+// checkpointFile is the checkpoint of the kubelet device manager which holds
+// the devices registered by device plugins
+const checkpointFile = "kubelet_internal_checkpoint"
+
+// checkpoint is the subset of the kubelet device manager checkpoint that we
+// are interested in.
+type checkpoint struct {
+	Data struct {
+		RegisteredDevices map[string][]string
+	}
+}
+
+// cdiSpec is the subset of a CDI spec that we are interested in.
+type cdiSpec struct {
+	Version string `json:"cdiVersion"`
+	Kind    string `json:"kind"`
+	Devices []struct {
+		Name string `json:"name"`
+	} `json:"devices"`
+}
+
+// checkpointPath returns the path of the device manager checkpoint.
+func checkpointPath() string {
+	return hostpath.VarDir.Path(devicePluginsDir, checkpointFile)
+}
+
+// cdiSpecDirs returns the directories where CDI specs are searched from, in
+// the order of increasing priority.
+func cdiSpecDirs() []string {
+	return []string{hostpath.EtcDir.Path("cdi"), hostpath.VarDir.Path("run/cdi")}
+}
+
+// detectResources returns the extended resources registered with the
+// kubelet by device plugins.
+func detectResources() ([]nfdv1alpha1.InstanceFeature, error) {
+	data, err := os.ReadFile(checkpointPath())
+	if err != nil {
+		return nil, err
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse device manager checkpoint: %w", err)
+	}
+
+	names := make([]string, 0, len(cp.Data.RegisteredDevices))
+	for name := range cp.Data.RegisteredDevices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resources := make([]nfdv1alpha1.InstanceFeature, 0, len(names))
+	for _, name := range names {
+		resources = append(resources, *nfdv1alpha1.NewInstanceFeature(map[string]string{
+			"name":    name,
+			"devices": strconv.Itoa(len(cp.Data.RegisteredDevices[name])),
+		}))
+	}
+	return resources, nil
+}
+
+// detectCdiSpecs returns the CDI specs present on the host.
+func detectCdiSpecs() ([]nfdv1alpha1.InstanceFeature, error) {
+	specs := make([]nfdv1alpha1.InstanceFeature, 0)
+
+	for _, dir := range cdiSpecDirs() {
+		files, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list CDI spec directory %q: %w", dir, err)
+		}
+
+		for _, f := range files {
+			ext := filepath.Ext(f.Name())
+			if f.IsDir() || (ext != ".yaml" && ext != ".json") {
+				continue
+			}
+			spec, err := readCdiSpec(filepath.Join(dir, f.Name()))
+			if err != nil {
+				klog.ErrorS(err, "failed to read CDI spec", "path", filepath.Join(dir, f.Name()))
+				continue
+			}
+			specs = append(specs, *nfdv1alpha1.NewInstanceFeature(map[string]string{
+				"kind":        spec.Kind,
+				"cdi_version": spec.Version,
+				"devices":     strconv.Itoa(len(spec.Devices)),
+				"spec":        f.Name(),
+			}))
+		}
+	}
+	return specs, nil
+}
+
+// readCdiSpec reads one CDI spec file. JSON is a subset of YAML so both
+// formats are parsed the same way.
+func readCdiSpec(path string) (*cdiSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &cdiSpec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+	if spec.Kind == "" {
+		return nil, fmt.Errorf("kind not specified")
+	}
+	return spec, nil
+}
+
+// sumDevices returns the total number of devices of the instances, keyed by
+// the given attribute. This makes it possible to match on the absence of a
+// resource or device kind.
+func sumDevices(instances []nfdv1alpha1.InstanceFeature, key string) map[string]string {
+	sums := make(map[string]int)
+	for _, i := range instances {
+		n, _ := strconv.Atoi(i.Attributes["devices"])
+		sums[i.Attributes[key]] += n
+	}
+	attrs := make(map[string]string, len(sums))
+	for k, v := range sums {
+		attrs[k] = strconv.Itoa(v)
+	}
+	return attrs
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kernel
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// bpfProgTypes maps BPF program types to the kernel config options required
+// by them
+var bpfProgTypes = map[string][]string{
+	"kprobe":     {"BPF_EVENTS", "KPROBE_EVENTS"},
+	"tracepoint": {"BPF_EVENTS"},
+	"perf_event": {"BPF_EVENTS"},
+	"xdp":        {"NET"},
+	"cgroup":     {"CGROUP_BPF"},
+	"sched_cls":  {"NET_CLS_BPF"},
+	"lsm":        {"BPF_LSM"},
+	"struct_ops": {"BPF_JIT", "DEBUG_INFO_BTF"},
+}
+
+// discoverCapabilities detects kernel capabilities relevant for workloads,
+// i.e. eBPF, io_uring, user namespaces, seccomp and Landlock support. The
+// detection is based on the kernel config (if available), sysfs and procfs.
+// Capabilities that can only be detected from the kernel config are omitted if
+// the kernel config is not available.
+func discoverCapabilities(kconfig map[string]string) map[string]string {
+	caps := make(map[string]string)
+	setKconfigCap := func(name string, supported bool) {
+		if kconfig != nil {
+			caps[name] = strconv.FormatBool(supported)
+		}
+	}
+	lsms, lsmsErr := utils.GetActiveLsms()
+
+	// eBPF
+	bpf := kconfigAny(kconfig, "BPF_SYSCALL") || pathExists(hostpath.ProcfsDir.Path("sys/kernel/unprivileged_bpf_disabled"))
+	caps["bpf"] = strconv.FormatBool(bpf)
+	setKconfigCap("bpf.jit", bpf && kconfigAll(kconfig, "BPF_JIT"))
+	caps["bpf.unprivileged"] = strconv.FormatBool(bpf && readSysctl("kernel/unprivileged_bpf_disabled") == "0")
+	caps["btf"] = strconv.FormatBool(pathExists(hostpath.SysfsDir.Path("kernel/btf/vmlinux")))
+	for progType, opts := range bpfProgTypes {
+		supported := bpf && kconfigAll(kconfig, opts...)
+		if progType == "lsm" {
+			// BPF LSM must also be active
+			supported = supported && lsms["bpf"]
+		}
+		setKconfigCap("bpf.prog."+progType, supported)
+	}
+
+	// io_uring, io_uring_disabled is 0 (enabled), 1 (restricted to the
+	// io_uring_group) or 2 (disabled)
+	ioUringDisabled := readSysctl("kernel/io_uring_disabled")
+	ioUring := kconfigAll(kconfig, "IO_URING") && ioUringDisabled != "2"
+	setKconfigCap("io_uring", ioUring)
+	setKconfigCap("io_uring.unprivileged", ioUring && (ioUringDisabled == "" || ioUringDisabled == "0"))
+
+	// User namespaces. Some distributions have additional knobs for
+	// restricting unprivileged user namespaces.
+	maxUserNs, _ := strconv.Atoi(readSysctl("user/max_user_namespaces"))
+	userns := kconfigAll(kconfig, "USER_NS") && maxUserNs > 0
+	setKconfigCap("userns", userns)
+	setKconfigCap("userns.unprivileged", userns &&
+		readSysctl("kernel/unprivileged_userns_clone") != "0" &&
+		readSysctl("kernel/apparmor_restrict_unprivileged_userns") != "1")
+
+	// Seccomp, actions_avail is only available with seccomp filter support
+	seccompFilter := kconfigAny(kconfig, "SECCOMP_FILTER") || pathExists(hostpath.ProcfsDir.Path("sys/kernel/seccomp/actions_avail"))
+	caps["seccomp"] = strconv.FormatBool(seccompFilter || kconfigAny(kconfig, "SECCOMP"))
+	caps["seccomp.filter"] = strconv.FormatBool(seccompFilter)
+
+	// Landlock must be built in and active
+	landlock := lsms["landlock"]
+	if lsmsErr != nil {
+		landlock = kconfigAny(kconfig, "SECURITY_LANDLOCK") && strings.Contains(kconfig["LSM"], "landlock")
+	}
+	caps["landlock"] = strconv.FormatBool(landlock)
+
+	return caps
+}
+
+// kconfigAll returns true if all of the given kernel config options are
+// enabled (built in or as a module).
+func kconfigAll(kconfig map[string]string, opts ...string) bool {
+	if kconfig == nil {
+		return false
+	}
+	for _, opt := range opts {
+		if v := kconfig[opt]; v != "y" && v != "m" {
+			return false
+		}
+	}
+	return true
+}
+
+// kconfigAny returns true if any of the given kernel config options is
+// enabled (built in or as a module).
+func kconfigAny(kconfig map[string]string, opts ...string) bool {
+	for _, opt := range opts {
+		if v := kconfig[opt]; v == "y" || v == "m" {
+			return true
+		}
+	}
+	return false
+}
+
+// readSysctl returns the value of a sysctl, or an empty string if the sysctl
+// does not exist.
+func readSysctl(name string) string {
+	data, err := os.ReadFile(hostpath.ProcfsDir.Path("sys", name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// pathExists returns true if the given path exists.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -62,21 +62,21 @@ func parseKconfig(configPath string) (realKconfig, legacyKconfig map[string]stri
 	kVer, err := getVersion()
 	if err != nil {
 		searchPaths = []string{
-			"/proc/config.gz",
+			hostpath.ProcfsDir.Path("config.gz"),
 			hostpath.UsrDir.Path("src/linux/.config"),
 		}
 	} else {
 		// from k8s.io/system-validator used by kubeadm
 		// preflight checks
 		searchPaths = []string{
-			"/proc/config.gz",
+			hostpath.ProcfsDir.Path("config.gz"),
 			hostpath.UsrDir.Path("src/linux-" + kVer + "/.config"),
 			hostpath.UsrDir.Path("src/linux/.config"),
 			hostpath.UsrDir.Path("lib/modules/" + kVer + "/config"),
 			hostpath.UsrDir.Path("lib/ostree-boot/config-" + kVer),
 			hostpath.UsrDir.Path("lib/kernel/config-" + kVer),
 			hostpath.UsrDir.Path("src/linux-headers-" + kVer + "/.config"),
-			"/lib/modules/" + kVer + "/build/.config",
+			hostpath.LibDir.Path("modules/" + kVer + "/build/.config"),
 			hostpath.BootDir.Path("config-" + kVer),
 		}
 	}
@@ -31,6 +31,7 @@ import (
 const Name = "kernel"
 
 const (
+	CapabilitiesFeature  = "capabilities"
 	ConfigFeature        = "config"
 	LoadedModuleFeature  = "loadedmodule"
 	SelinuxFeature       = "selinux"
@@ -122,14 +123,16 @@ func (s *kernelSource) Discover() error {
 	s.features = nfdv1alpha1.NewFeatures()
 
 	// Read kernel version
-	if version, err := discoverVersion(); err != nil {
+	version, err := discoverVersion()
+	if err != nil {
 		klog.ErrorS(err, "failed to get kernel version")
 	} else {
 		s.features.Attributes[VersionFeature] = nfdv1alpha1.NewAttributeFeatures(version)
 	}
 
 	// Read kconfig
-	if realKconfig, legacyKconfig, err := parseKconfig(s.config.KconfigFile); err != nil {
+	realKconfig, legacyKconfig, err := parseKconfig(s.config.KconfigFile)
+	if err != nil {
 		s.legacyKconfig = nil
 		klog.ErrorS(err, "failed to read kconfig")
 	} else {
@@ -137,6 +140,9 @@ func (s *kernelSource) Discover() error {
 		s.legacyKconfig = legacyKconfig
 	}
 
+	// Detect kernel capabilities, based on the kconfig
+	s.features.Attributes[CapabilitiesFeature] = nfdv1alpha1.NewAttributeFeatures(discoverCapabilities(realKconfig))
+
 	var enabledModules []string
 	if kmods, err := getLoadedModules(); err != nil {
 		klog.ErrorS(err, "failed to get loaded kernel modules")
@@ -17,11 +17,24 @@ limitations under the License.
 package kernel
 
 import (
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
 func TestKernelSource(t *testing.T) {
 	assert.Equal(t, src.Name(), Name)
 
@@ -33,3 +46,87 @@ func TestKernelSource(t *testing.T) {
 	assert.Empty(t, l)
 
 }
+
+func TestKernelSourceRootfs(t *testing.T) {
+	hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", "rootfs-1"))
+	defer hostpath.SetHostRoot("/")
+
+	testSrc := kernelSource{config: newDefaultConfig()}
+	assert.Nil(t, testSrc.Discover())
+
+	f := testSrc.GetFeatures()
+	assert.Equal(t, map[string]string{
+		"full":     "6.1.0-test",
+		"major":    "6",
+		"minor":    "1",
+		"revision": "0",
+	}, f.Attributes[VersionFeature].Elements)
+	assert.Equal(t, map[string]string{
+		"NO_HZ":             "y",
+		"NO_HZ_IDLE":        "y",
+		"PREEMPT_VOLUNTARY": "y",
+		"DEFAULT_HOSTNAME":  "(none)",
+		"KVM":               "m",
+		"NET":               "y",
+		"BPF_SYSCALL":       "y",
+		"BPF_JIT":           "y",
+		"BPF_EVENTS":        "y",
+		"KPROBE_EVENTS":     "y",
+		"CGROUP_BPF":        "y",
+		"NET_CLS_BPF":       "m",
+		"BPF_LSM":           "y",
+		"IO_URING":          "y",
+		"USER_NS":           "y",
+		"SECCOMP":           "y",
+		"SECCOMP_FILTER":    "y",
+		"SECURITY_LANDLOCK": "y",
+		"LSM":               "landlock,lockdown,yama,apparmor",
+	}, f.Attributes[ConfigFeature].Elements)
+	assert.Equal(t, map[string]string{
+		"bpf":                   "true",
+		"bpf.jit":               "true",
+		"bpf.unprivileged":      "false",
+		"btf":                   "true",
+		"bpf.prog.kprobe":       "true",
+		"bpf.prog.tracepoint":   "true",
+		"bpf.prog.perf_event":   "true",
+		"bpf.prog.xdp":          "true",
+		"bpf.prog.cgroup":       "true",
+		"bpf.prog.sched_cls":    "true",
+		"bpf.prog.lsm":          "false",
+		"bpf.prog.struct_ops":   "false",
+		"io_uring":              "true",
+		"io_uring.unprivileged": "false",
+		"userns":                "true",
+		"userns.unprivileged":   "false",
+		"seccomp":               "true",
+		"seccomp.filter":        "true",
+		"landlock":              "true",
+	}, f.Attributes[CapabilitiesFeature].Elements)
+	assert.Equal(t, nfdv1alpha1.NewFlagFeatures("kvm_intel", "kvm", "ib_uverbs"), f.Flags[LoadedModuleFeature])
+	assert.Equal(t, nfdv1alpha1.NewFlagFeatures("kvm_intel", "kvm", "ib_uverbs", "sha256_generic", "ext4"), f.Flags[EnabledModuleFeature])
+	assert.Equal(t, "true", f.Attributes[SelinuxFeature].Elements["enabled"])
+
+	l, err := testSrc.GetLabels()
+	assert.Nil(t, err, err)
+	assert.Equal(t, source.FeatureLabels{
+		"version.full":      "6.1.0-test",
+		"version.major":     "6",
+		"version.minor":     "1",
+		"version.revision":  "0",
+		"config.NO_HZ":      "true",
+		"config.NO_HZ_IDLE": "true",
+		"selinux.enabled":   "true",
+	}, l)
+}
+
+func TestDiscoverCapabilitiesWithoutKconfig(t *testing.T) {
+	hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", "rootfs-1"))
+	defer hostpath.SetHostRoot("/")
+
+	caps := discoverCapabilities(nil)
+	assert.Equal(t, "true", caps["seccomp.filter"])
+	for _, name := range []string{"bpf.jit", "bpf.prog.kprobe", "io_uring", "userns"} {
+		assert.NotContains(t, caps, name)
+	}
+}
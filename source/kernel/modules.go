@@ -25,9 +25,8 @@ import (
 	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 )
 
-const kmodProcfsPath = "/proc/modules"
-
 func getLoadedModules() ([]string, error) {
+	kmodProcfsPath := hostpath.ProcfsDir.Path("modules")
 	out, err := os.ReadFile(kmodProcfsPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s: %s", kmodProcfsPath, err.Error())
@@ -20,6 +20,8 @@ import (
 	"os"
 	"regexp"
 	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 )
 
 // Read and parse kernel version
@@ -57,7 +59,7 @@ func parseVersion(raw string) map[string]string {
 }
 
 func getVersion() (string, error) {
-	unameRaw, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	unameRaw, err := os.ReadFile(hostpath.ProcfsDir.Path("sys/kernel/osrelease"))
 	if err != nil {
 		return "", err
 	}
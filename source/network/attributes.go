@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package network
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+)
+
+// ethtoolDrvinfo is used for getting the ethtool driver info of a network
+// interface, replaceable in tests.
+var ethtoolDrvinfo = unix.IoctlGetEthtoolDrvinfo
+
+// errAttrNotAvailable is returned by attribute readers when the attribute
+// does not apply to the network interface.
+var errAttrNotAvailable = errors.New("attribute not available")
+
+// ifaceAttrReader reads one attribute of the network interface whose sysfs
+// directory is given as the argument.
+type ifaceAttrReader func(ifacePath string) (string, error)
+
+// ifaceAttrReaders contains all supported network interface attributes.
+var ifaceAttrReaders = map[string]ifaceAttrReader{
+	"operstate":        sysfsAttr("operstate"),
+	"speed":            sysfsAttr("speed"),
+	"mtu":              sysfsAttr("mtu"),
+	"carrier":          sysfsAttr("carrier"),
+	"duplex":           sysfsAttr("duplex"),
+	"sriov_numvfs":     sysfsAttr("device/sriov_numvfs"),
+	"sriov_totalvfs":   sysfsAttr("device/sriov_totalvfs"),
+	"numa_node":        sysfsAttr("device/numa_node"),
+	"driver":           sysfsLinkAttr("device/driver"),
+	"mac_oui":          readMacOui,
+	"pci_address":      readPciAddress,
+	"rdma_device":      readRdmaDevice,
+	"firmware_version": readFirmwareVersion,
+}
+
+// sysfsAttr returns a reader for a plain sysfs attribute file.
+func sysfsAttr(file string) ifaceAttrReader {
+	return func(ifacePath string) (string, error) {
+		return utils.ReadSysfsAttr(ifacePath, file)
+	}
+}
+
+// sysfsLinkAttr returns a reader that resolves the name of a sysfs link
+// target, e.g. the driver bound to the device.
+func sysfsLinkAttr(link string) ifaceAttrReader {
+	return func(ifacePath string) (string, error) {
+		target, err := os.Readlink(filepath.Join(ifacePath, link))
+		if err != nil {
+			return "", err
+		}
+		return filepath.Base(target), nil
+	}
+}
+
+// readMacOui returns the Organizationally Unique Identifier, i.e. the first
+// three octets, of the MAC address of the interface.
+func readMacOui(ifacePath string) (string, error) {
+	addr, err := sysfsAttr("address")(ifacePath)
+	if err != nil {
+		return "", err
+	}
+	octets := strings.Split(addr, ":")
+	if len(octets) < 3 || strings.Trim(addr, "0:") == "" {
+		return "", errAttrNotAvailable
+	}
+	return strings.Join(octets[:3], ":"), nil
+}
+
+// readPciAddress returns the PCI address of the device of the interface.
+func readPciAddress(ifacePath string) (string, error) {
+	subsystem, err := sysfsLinkAttr("device/subsystem")(ifacePath)
+	if err != nil {
+		return "", err
+	}
+	if subsystem != "pci" {
+		return "", errAttrNotAvailable
+	}
+	return sysfsLinkAttr("device")(ifacePath)
+}
+
+// readRdmaDevice returns the name(s) of the RDMA device(s) associated with
+// the interface.
+func readRdmaDevice(ifacePath string) (string, error) {
+	entries, err := os.ReadDir(filepath.Join(ifacePath, "device/infiniband"))
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) == 0 {
+		return "", errAttrNotAvailable
+	}
+	return strings.Join(names, ","), nil
+}
+
+// readFirmwareVersion reads the firmware version of the interface using the
+// ETHTOOL_GDRVINFO ioctl. This only works if nfd-worker runs in the host
+// network namespace.
+func readFirmwareVersion(ifacePath string) (string, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return "", err
+	}
+	defer unix.Close(fd)
+
+	info, err := ethtoolDrvinfo(fd, filepath.Base(ifacePath))
+	if err != nil {
+		if errors.Is(err, unix.ENODEV) || errors.Is(err, unix.EOPNOTSUPP) {
+			return "", errAttrNotAvailable
+		}
+		return "", err
+	}
+	fw := unix.ByteSliceToString(info.Fw_version[:])
+	if fw == "" {
+		return "", errAttrNotAvailable
+	}
+	return fw, nil
+}
@@ -19,10 +19,10 @@ package network
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"syscall"
 
 	"k8s.io/klog/v2"
@@ -45,29 +45,58 @@ const (
 
 const sysfsBaseDir = "class/net"
 
-// networkSource implements the FeatureSource and LabelSource interfaces.
+// Config holds the configuration parameters of this source.
+type Config struct {
+	// DeviceAttributes is the list of attributes to discover for physical
+	// network interfaces.
+	DeviceAttributes []string `json:"deviceAttributes,omitempty"`
+	// VirtualAttributes is the list of attributes to discover for virtual
+	// network interfaces.
+	VirtualAttributes []string `json:"virtualAttributes,omitempty"`
+}
+
+// newDefaultConfig returns a new config with pre-populated defaults
+func newDefaultConfig() *Config {
+	return &Config{
+		DeviceAttributes: []string{"operstate", "speed", "sriov_numvfs", "sriov_totalvfs",
+			"mtu", "carrier", "duplex", "mac_oui", "driver", "numa_node", "pci_address", "rdma_device"},
+		VirtualAttributes: []string{"operstate", "speed"},
+	}
+}
+
+// networkSource implements the FeatureSource, LabelSource and ConfigurableSource interfaces.
 type networkSource struct {
+	config   *Config
 	features *nfdv1alpha1.Features
 }
 
 // Singleton source instance
 var (
-	src networkSource
-	_   source.FeatureSource = &src
-	_   source.LabelSource   = &src
-)
-
-var (
-	// devIfaceAttrs is the list of files under /sys/class/net/<iface> that we're reading
-	devIfaceAttrs = []string{"operstate", "speed", "device/sriov_numvfs", "device/sriov_totalvfs"}
-
-	// virtualIfaceAttrs is the list of files under /sys/class/net/<iface> that we're reading
-	virtualIfaceAttrs = []string{"operstate", "speed"}
+	src                           = networkSource{config: newDefaultConfig()}
+	_   source.FeatureSource      = &src
+	_   source.LabelSource        = &src
+	_   source.ConfigurableSource = &src
 )
 
 // Name returns an identifier string for this feature source.
 func (s *networkSource) Name() string { return Name }
 
+// NewConfig method of the LabelSource interface
+func (s *networkSource) NewConfig() source.Config { return newDefaultConfig() }
+
+// GetConfig method of the LabelSource interface
+func (s *networkSource) GetConfig() source.Config { return s.config }
+
+// SetConfig method of the LabelSource interface
+func (s *networkSource) SetConfig(conf source.Config) {
+	switch v := conf.(type) {
+	case *Config:
+		s.config = v
+	default:
+		panic(fmt.Sprintf("invalid config type: %T", conf))
+	}
+}
+
 // Priority method of the LabelSource interface
 func (s *networkSource) Priority() int { return 0 }
 
@@ -101,7 +130,7 @@ func (s *networkSource) GetLabels() (source.FeatureLabels, error) {
 func (s *networkSource) Discover() error {
 	s.features = nfdv1alpha1.NewFeatures()
 
-	devs, virts, err := detectNetDevices()
+	devs, virts, err := detectNetDevices(s.config.DeviceAttributes, s.config.VirtualAttributes)
 	if err != nil {
 		return fmt.Errorf("failed to detect network devices: %w", err)
 	}
@@ -121,7 +150,7 @@ func (s *networkSource) GetFeatures() *nfdv1alpha1.Features {
 	return s.features
 }
 
-func detectNetDevices() ([]nfdv1alpha1.InstanceFeature, []nfdv1alpha1.InstanceFeature, error) {
+func detectNetDevices(devAttrs, virtualAttrs []string) ([]nfdv1alpha1.InstanceFeature, []nfdv1alpha1.InstanceFeature, error) {
 	sysfsBasePath := hostpath.SysfsDir.Path(sysfsBaseDir)
 
 	ifaces, err := os.ReadDir(sysfsBasePath)
@@ -136,27 +165,31 @@ func detectNetDevices() ([]nfdv1alpha1.InstanceFeature, []nfdv1alpha1.InstanceFe
 	for _, iface := range ifaces {
 		name := iface.Name()
 		if _, err := os.Stat(filepath.Join(sysfsBasePath, name, "device")); err == nil {
-			devIfacesinfo = append(devIfacesinfo, readIfaceInfo(filepath.Join(sysfsBasePath, name), devIfaceAttrs))
+			devIfacesinfo = append(devIfacesinfo, readIfaceInfo(filepath.Join(sysfsBasePath, name), devAttrs))
 		} else {
-			virtualIfacesinfo = append(virtualIfacesinfo, readIfaceInfo(filepath.Join(sysfsBasePath, name), virtualIfaceAttrs))
+			virtualIfacesinfo = append(virtualIfacesinfo, readIfaceInfo(filepath.Join(sysfsBasePath, name), virtualAttrs))
 		}
 	}
 
 	return devIfacesinfo, virtualIfacesinfo, nil
 }
 
-func readIfaceInfo(path string, attrFiles []string) nfdv1alpha1.InstanceFeature {
+func readIfaceInfo(path string, attrNames []string) nfdv1alpha1.InstanceFeature {
 	attrs := map[string]string{"name": filepath.Base(path)}
-	for _, attrFile := range attrFiles {
-		data, err := os.ReadFile(filepath.Join(path, attrFile))
+	for _, attrName := range attrNames {
+		reader, ok := ifaceAttrReaders[attrName]
+		if !ok {
+			klog.InfoS("ignoring unknown net iface attribute", "attributeName", attrName)
+			continue
+		}
+		val, err := reader(path)
 		if err != nil {
-			if !os.IsNotExist(err) && !errors.Is(err, syscall.EINVAL) {
-				klog.ErrorS(err, "failed to read net iface attribute", "attributeName", attrFile)
+			if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, errAttrNotAvailable) {
+				klog.ErrorS(err, "failed to read net iface attribute", "attributeName", attrName)
 			}
 			continue
 		}
-		attrName := filepath.Base(attrFile)
-		attrs[attrName] = strings.TrimSpace(string(data))
+		attrs[attrName] = val
 	}
 
 	return *nfdv1alpha1.NewInstanceFeature(attrs)
@@ -17,11 +17,25 @@ limitations under the License.
 package network
 
 import (
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
 func TestNetworkSource(t *testing.T) {
 	assert.Equal(t, src.Name(), Name)
 
@@ -33,3 +47,142 @@ func TestNetworkSource(t *testing.T) {
 	assert.Empty(t, l)
 
 }
+
+func TestNetworkSourceRootfs(t *testing.T) {
+	origSysfsDir := hostpath.SysfsDir
+	hostpath.SysfsDir = hostpath.HostDir(filepath.Join(packagePath, "testdata", "rootfs-1", "sys"))
+	defer func() { hostpath.SysfsDir = origSysfsDir }()
+
+	origEthtoolDrvinfo := ethtoolDrvinfo
+	defer func() { ethtoolDrvinfo = origEthtoolDrvinfo }()
+	ethtoolDrvinfo = func(fd int, ifname string) (*unix.EthtoolDrvinfo, error) {
+		if ifname != "enp59s0f0" {
+			return nil, unix.EOPNOTSUPP
+		}
+		info := &unix.EthtoolDrvinfo{}
+		copy(info.Fw_version[:], "16.35.2000")
+		return info, nil
+	}
+
+	tests := []struct {
+		name             string
+		config           *Config
+		expectedFeatures *nfdv1alpha1.Features
+		expectedLabels   source.FeatureLabels
+	}{
+		{
+			name: "default config",
+			expectedFeatures: &nfdv1alpha1.Features{
+				Flags:      map[string]nfdv1alpha1.FlagFeatureSet{},
+				Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{},
+				Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+					DeviceFeature: {
+						Elements: []nfdv1alpha1.InstanceFeature{
+							{
+								Attributes: map[string]string{
+									"name":        "eno1",
+									"operstate":   "down",
+									"mtu":         "1500",
+									"duplex":      "unknown",
+									"mac_oui":     "3c:fd:fe",
+									"driver":      "e1000e",
+									"numa_node":   "-1",
+									"pci_address": "0000:00:1f.6",
+								},
+							},
+							{
+								Attributes: map[string]string{
+									"name":           "enp59s0f0",
+									"operstate":      "up",
+									"speed":          "100000",
+									"sriov_numvfs":   "4",
+									"sriov_totalvfs": "8",
+									"mtu":            "9000",
+									"carrier":        "1",
+									"duplex":         "full",
+									"mac_oui":        "b8:ce:f6",
+									"driver":         "mlx5_core",
+									"numa_node":      "0",
+									"pci_address":    "0000:3b:00.0",
+									"rdma_device":    "mlx5_0",
+								},
+							},
+						},
+					},
+					VirtualFeature: {
+						Elements: []nfdv1alpha1.InstanceFeature{
+							{
+								Attributes: map[string]string{
+									"name":      "lo",
+									"operstate": "unknown",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedLabels: source.FeatureLabels{
+				"sriov.capable":    true,
+				"sriov.configured": true,
+			},
+		},
+		{
+			name: "custom attributes",
+			config: &Config{
+				DeviceAttributes:  []string{"speed", "rdma_device", "firmware_version", "unknown"},
+				VirtualAttributes: []string{"mtu", "mac_oui"},
+			},
+			expectedFeatures: &nfdv1alpha1.Features{
+				Flags:      map[string]nfdv1alpha1.FlagFeatureSet{},
+				Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{},
+				Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+					DeviceFeature: {
+						Elements: []nfdv1alpha1.InstanceFeature{
+							{
+								Attributes: map[string]string{
+									"name": "eno1",
+								},
+							},
+							{
+								Attributes: map[string]string{
+									"name":             "enp59s0f0",
+									"speed":            "100000",
+									"rdma_device":      "mlx5_0",
+									"firmware_version": "16.35.2000",
+								},
+							},
+						},
+					},
+					VirtualFeature: {
+						Elements: []nfdv1alpha1.InstanceFeature{
+							{
+								Attributes: map[string]string{
+									"name": "lo",
+									"mtu":  "65536",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedLabels: source.FeatureLabels{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			if config == nil {
+				config = newDefaultConfig()
+			}
+			testSrc := networkSource{config: config}
+
+			assert.Nil(t, testSrc.Discover())
+			assert.Equal(t, tc.expectedFeatures, testSrc.GetFeatures())
+
+			l, err := testSrc.GetLabels()
+			assert.Nil(t, err, err)
+			assert.Equal(t, tc.expectedLabels, l)
+		})
+	}
+}
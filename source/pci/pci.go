@@ -36,8 +36,9 @@ const DeviceFeature = "device"
 
 // Config holds the configuration parameters of this source.
 type Config struct {
-	DeviceClassWhitelist []string `json:"deviceClassWhitelist,omitempty"`
-	DeviceLabelFields    []string `json:"deviceLabelFields,omitempty"`
+	DeviceClassWhitelist  []string `json:"deviceClassWhitelist,omitempty"`
+	DeviceLabelFields     []string `json:"deviceLabelFields,omitempty"`
+	ExtraDeviceAttributes []string `json:"extraDeviceAttributes,omitempty"`
 }
 
 // newDefaultConfig returns a new config with pre-populated defaults
@@ -139,7 +140,7 @@ func (s *pciSource) GetLabels() (source.FeatureLabels, error) {
 func (s *pciSource) Discover() error {
 	s.features = nfdv1alpha1.NewFeatures()
 
-	devs, err := detectPci()
+	devs, err := detectPci(s.config.ExtraDeviceAttributes)
 	if err != nil {
 		return fmt.Errorf("failed to detect PCI devices: %s", err.Error())
 	}
@@ -62,96 +62,130 @@ func TestPciSource(t *testing.T) {
 					Elements: []nfdv1alpha1.InstanceFeature{
 						{
 							Attributes: map[string]string{
-								"class":            "0880",
-								"device":           "2021",
-								"subsystem_device": "35cf",
-								"subsystem_vendor": "8086",
-								"vendor":           "8086",
+								"class":              "0880",
+								"current_link_speed": "Unknown",
+								"current_link_width": "0",
+								"device":             "2021",
+								"numa_node":          "0",
+								"subsystem_device":   "35cf",
+								"subsystem_vendor":   "8086",
+								"vendor":             "8086",
+								"vfio_bound":         "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":            "ff00",
 								"device":           "a1ed",
+								"numa_node":        "0",
 								"subsystem_device": "35cf",
 								"subsystem_vendor": "8086",
 								"vendor":           "8086",
+								"vfio_bound":       "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":            "0106",
 								"device":           "a1d2",
+								"numa_node":        "0",
 								"subsystem_device": "35cf",
 								"subsystem_vendor": "8086",
 								"vendor":           "8086",
+								"vfio_bound":       "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":            "1180",
 								"device":           "a1b1",
+								"numa_node":        "0",
 								"subsystem_device": "35cf",
 								"subsystem_vendor": "8086",
 								"vendor":           "8086",
+								"vfio_bound":       "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":            "0780",
 								"device":           "a1ba",
+								"numa_node":        "0",
 								"subsystem_device": "35cf",
 								"subsystem_vendor": "8086",
 								"vendor":           "8086",
+								"vfio_bound":       "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
-								"class":            "0604",
-								"device":           "a193",
-								"subsystem_device": "35cf",
-								"subsystem_vendor": "8086",
-								"vendor":           "8086",
+								"class":              "0604",
+								"current_link_speed": "5.0 GT/s PCIe",
+								"current_link_width": "1",
+								"device":             "a193",
+								"numa_node":          "0",
+								"subsystem_device":   "35cf",
+								"subsystem_vendor":   "8086",
+								"vendor":             "8086",
+								"vfio_bound":         "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":            "0c80",
 								"device":           "a1a4",
+								"numa_node":        "0",
 								"subsystem_device": "35cf",
 								"subsystem_vendor": "8086",
 								"vendor":           "8086",
+								"vfio_bound":       "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":            "0300",
 								"device":           "2000",
+								"driver":           "ast",
+								"numa_node":        "0",
 								"subsystem_device": "2000",
 								"subsystem_vendor": "1a03",
 								"vendor":           "1a03",
+								"vfio_bound":       "false",
 							},
 						},
 						{
 							Attributes: map[string]string{
 								"class":                     "0b40",
+								"current_link_speed":        "5.0 GT/s PCIe",
+								"current_link_width":        "16",
 								"device":                    "37c8",
+								"driver":                    "vfio-pci",
 								"iommu/intel-iommu/version": "1:0",
+								"iommu_group":               "12",
 								"iommu_group/type":          "identity",
+								"numa_node":                 "0",
+								"sriov_numvfs":              "16",
 								"sriov_totalvfs":            "16",
 								"subsystem_device":          "35cf",
 								"subsystem_vendor":          "8086",
 								"vendor":                    "8086",
+								"vfio_bound":                "true",
 							},
 						},
 						{
 							Attributes: map[string]string{
-								"class":            "0200",
-								"device":           "37d2",
-								"sriov_totalvfs":   "32",
-								"subsystem_device": "35cf",
-								"subsystem_vendor": "8086",
-								"vendor":           "8086",
+								"class":              "0200",
+								"current_link_speed": "2.5 GT/s PCIe",
+								"current_link_width": "1",
+								"device":             "37d2",
+								"driver":             "i40e",
+								"numa_node":          "0",
+								"sriov_numvfs":       "0",
+								"sriov_totalvfs":     "32",
+								"subsystem_device":   "35cf",
+								"subsystem_vendor":   "8086",
+								"vendor":             "8086",
+								"vfio_bound":         "false",
 							},
 						},
 					},
@@ -259,3 +293,15 @@ func TestPciSource(t *testing.T) {
 		})
 	}
 }
+
+func TestReadPciDevInfoExtraAttrs(t *testing.T) {
+	devPath := filepath.Join(packagePath, "testdata", "rootfs-1", "sys/bus/pci/devices/0000:3f:00.0")
+
+	info, err := readPciDevInfo(devPath, []string{"max_link_speed", "local_cpulist", "non_existent"})
+	assert.Nil(t, err, err)
+	assert.Equal(t, "i40e", info.Attributes["driver"])
+	assert.Equal(t, "false", info.Attributes["vfio_bound"])
+	assert.Equal(t, "2.5 GT/s PCIe", info.Attributes["max_link_speed"])
+	assert.Equal(t, "0-2,5-6,10-12,15-16,40-42,45-46,50-52,55-56", info.Attributes["local_cpulist"])
+	assert.NotContains(t, info.Attributes, "non_existent")
+}
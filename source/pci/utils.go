@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"k8s.io/klog/v2"
@@ -29,7 +30,12 @@ import (
 )
 
 var mandatoryDevAttrs = []string{"class", "vendor", "device", "subsystem_vendor", "subsystem_device"}
-var optionalDevAttrs = []string{"sriov_totalvfs", "iommu_group/type", "iommu/intel-iommu/version"}
+var optionalDevAttrs = []string{"sriov_totalvfs", "sriov_numvfs", "iommu_group/type", "iommu/intel-iommu/version",
+	"numa_node", "current_link_speed", "current_link_width"}
+
+// vfioPciDriver is the name of the driver used for passing through PCI
+// devices to user space
+const vfioPciDriver = "vfio-pci"
 
 // Read a single PCI device attribute
 // A PCI attribute in this context, maps to the corresponding sysfs file
@@ -49,8 +55,18 @@ func readSinglePciAttribute(devPath string, attrName string) (string, error) {
 	return attrVal, nil
 }
 
+// Read the name of the target of a symlink in the device directory, e.g. the
+// name of the bound driver.
+func readPciDevLink(devPath string, linkName string) (string, error) {
+	target, err := os.Readlink(filepath.Join(devPath, linkName))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Base(target), nil
+}
+
 // Read information of one PCI device
-func readPciDevInfo(devPath string) (*nfdv1alpha1.InstanceFeature, error) {
+func readPciDevInfo(devPath string, extraAttrs []string) (*nfdv1alpha1.InstanceFeature, error) {
 	attrs := make(map[string]string)
 	for _, attr := range mandatoryDevAttrs {
 		attrVal, err := readSinglePciAttribute(devPath, attr)
@@ -59,18 +75,31 @@ func readPciDevInfo(devPath string) (*nfdv1alpha1.InstanceFeature, error) {
 		}
 		attrs[attr] = attrVal
 	}
-	for _, attr := range optionalDevAttrs {
+	for _, attr := range append(optionalDevAttrs, extraAttrs...) {
 		attrVal, err := readSinglePciAttribute(devPath, attr)
 		if err == nil {
 			attrs[attr] = attrVal
 		}
 	}
+
+	// Derived attributes
+	driver, err := readPciDevLink(devPath, "driver")
+	if err == nil {
+		attrs["driver"] = driver
+	}
+	attrs["vfio_bound"] = strconv.FormatBool(driver == vfioPciDriver)
+	if group, err := readPciDevLink(devPath, "iommu_group"); err == nil {
+		attrs["iommu_group"] = group
+	}
+
 	return nfdv1alpha1.NewInstanceFeature(attrs), nil
 }
 
 // detectPci detects available PCI devices and retrieves their device attributes.
 // An error is returned if reading any of the mandatory attributes fails.
-func detectPci() ([]nfdv1alpha1.InstanceFeature, error) {
+// Extra sysfs attributes specified in extraAttrs are read in addition to the
+// default set of attributes.
+func detectPci(extraAttrs []string) ([]nfdv1alpha1.InstanceFeature, error) {
 	sysfsBasePath := hostpath.SysfsDir.Path("bus/pci/devices")
 
 	devices, err := os.ReadDir(sysfsBasePath)
@@ -81,7 +110,7 @@ func detectPci() ([]nfdv1alpha1.InstanceFeature, error) {
 	// Iterate over devices
 	devInfo := make([]nfdv1alpha1.InstanceFeature, 0, len(devices))
 	for _, device := range devices {
-		info, err := readPciDevInfo(filepath.Join(sysfsBasePath, device.Name()))
+		info, err := readPciDevInfo(filepath.Join(sysfsBasePath, device.Name()), extraAttrs)
 		if err != nil {
 			klog.ErrorS(err, "failed to read PCI device info")
 			continue
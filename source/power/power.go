@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package power
+
+import (
+	"errors"
+	"io/fs"
+
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Name of this feature source
+const Name = "power"
+
+const (
+	// StatusFeature exposes a summary of the power supply of the system
+	StatusFeature = "status"
+	// SupplyFeature exposes the power supplies of the system
+	SupplyFeature = "supply"
+	// ThermalFeature exposes the thermal zones of the system
+	ThermalFeature = "thermal"
+	// PowercapFeature exposes the power capping zones (e.g. RAPL) of the system
+	PowercapFeature = "powercap"
+)
+
+// powerSource implements the FeatureSource interface.
+type powerSource struct {
+	features *nfdv1alpha1.Features
+}
+
+// Singleton source instance
+var (
+	src powerSource
+	_   source.FeatureSource = &src
+)
+
+// Name returns an identifier string for this feature source.
+func (s *powerSource) Name() string { return Name }
+
+// Discover method of the FeatureSource interface
+func (s *powerSource) Discover() error {
+	s.features = nfdv1alpha1.NewFeatures()
+
+	if supplies, err := detectPowerSupplies(); err == nil {
+		s.features.Instances[SupplyFeature] = nfdv1alpha1.NewInstanceFeatures(supplies)
+		s.features.Attributes[StatusFeature] = nfdv1alpha1.NewAttributeFeatures(powerStatus(supplies))
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		klog.ErrorS(err, "failed to detect power supplies")
+	}
+
+	if zones, err := detectThermalZones(); err == nil {
+		s.features.Instances[ThermalFeature] = nfdv1alpha1.NewInstanceFeatures(zones)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		klog.ErrorS(err, "failed to detect thermal zones")
+	}
+
+	if zones, err := detectPowercap(); err == nil {
+		s.features.Instances[PowercapFeature] = nfdv1alpha1.NewInstanceFeatures(zones)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		klog.ErrorS(err, "failed to detect powercap zones")
+	}
+
+	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
+
+	return nil
+}
+
+// GetFeatures method of the FeatureSource Interface.
+func (s *powerSource) GetFeatures() *nfdv1alpha1.Features {
+	if s.features == nil {
+		s.features = nfdv1alpha1.NewFeatures()
+	}
+	return s.features
+}
+
+func init() {
+	source.Register(&src)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package power
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
+func TestPowerSource(t *testing.T) {
+	assert.Equal(t, src.Name(), Name)
+
+	expectedFeatures := map[string]*nfdv1alpha1.Features{
+		"rootfs-empty": nfdv1alpha1.NewFeatures(),
+		"rootfs-1": &nfdv1alpha1.Features{
+			Flags: map[string]nfdv1alpha1.FlagFeatureSet{},
+			Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{
+				StatusFeature: nfdv1alpha1.NewAttributeFeatures(map[string]string{
+					"ac_online":        "true",
+					"battery_present":  "true",
+					"battery_capacity": "87",
+				}),
+			},
+			Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+				SupplyFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":   "AC",
+						"type":   "Mains",
+						"online": "1",
+					}),
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":     "BAT0",
+						"type":     "Battery",
+						"present":  "1",
+						"status":   "Discharging",
+						"capacity": "87",
+					}),
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":     "hidpp_battery_0",
+						"type":     "Battery",
+						"present":  "1",
+						"status":   "Discharging",
+						"capacity": "50",
+						"scope":    "Device",
+					}),
+				}),
+				ThermalFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":          "thermal_zone0",
+						"type":          "x86_pkg_temp",
+						"temp":          "45000",
+						"policy":        "step_wise",
+						"trip.passive":  "95000",
+						"trip.critical": "105000",
+					}),
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":          "thermal_zone1",
+						"type":          "acpitz",
+						"temp":          "27800",
+						"policy":        "step_wise",
+						"trip.passive":  "90000",
+						"trip.critical": "119000",
+					}),
+				}),
+				PowercapFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+					*nfdv1alpha1.NewInstanceFeature(map[string]string{
+						"name":                                 "intel-rapl:0",
+						"zone_name":                            "package-0",
+						"enabled":                              "1",
+						"max_power_range_uw":                   "262143328850",
+						"constraint.long_term.power_limit_uw":  "125000000",
+						"constraint.long_term.max_power_uw":    "125000000",
+						"constraint.long_term.time_window_us":  "27983872",
+						"constraint.short_term.power_limit_uw": "150000000",
+						"constraint.short_term.time_window_us": "2440",
+					}),
+				}),
+			},
+		},
+	}
+
+	for rootfs, expected := range expectedFeatures {
+		t.Run(rootfs, func(t *testing.T) {
+			hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", rootfs))
+			defer hostpath.SetHostRoot("/")
+
+			testSrc := powerSource{}
+			assert.Nil(t, testSrc.Discover())
+			assert.Equal(t, expected, testSrc.GetFeatures())
+		})
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// supplyAttrs is the list of files under /sys/class/power_supply/<supply>
+// that we're reading
+var supplyAttrs = []string{"type", "online", "present", "status", "capacity", "scope"}
+
+// thermalZoneRe matches the thermal zones under /sys/class/thermal, i.e.
+// excluding cooling devices
+var thermalZoneRe = regexp.MustCompile(`^thermal_zone\d+$`)
+
+// tripPointRe matches the type files of the trip points of a thermal zone
+var tripPointRe = regexp.MustCompile(`^trip_point_(\d+)_type$`)
+
+// constraintRe matches the name files of the constraints of a powercap zone
+var constraintRe = regexp.MustCompile(`^constraint_(\d+)_name$`)
+
+// detectPowerSupplies detects the power supplies (AC adapters, batteries,
+// UPSes etc.) of the system.
+func detectPowerSupplies() ([]nfdv1alpha1.InstanceFeature, error) {
+	basePath := hostpath.SysfsDir.Path("class/power_supply")
+
+	supplies, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := make([]nfdv1alpha1.InstanceFeature, 0, len(supplies))
+	for _, supply := range supplies {
+		supplyPath := filepath.Join(basePath, supply.Name())
+		attrs := map[string]string{"name": supply.Name()}
+		for _, attr := range supplyAttrs {
+			if v, err := utils.ReadSysfsAttr(supplyPath, attr); err == nil {
+				attrs[attr] = v
+			}
+		}
+		info = append(info, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return info, nil
+}
+
+// powerStatus summarizes the state of the power supplies of the system.
+// Peripheral devices (e.g. the battery of a wireless mouse) are ignored.
+func powerStatus(supplies []nfdv1alpha1.InstanceFeature) map[string]string {
+	acOnline := false
+	batteryPresent := false
+	batteryCapacity := -1
+	for _, s := range supplies {
+		attrs := s.Attributes
+		if attrs["scope"] == "Device" {
+			continue
+		}
+		switch attrs["type"] {
+		case "Mains", "USB":
+			if attrs["online"] == "1" {
+				acOnline = true
+			}
+		case "Battery", "UPS":
+			if attrs["present"] == "0" {
+				continue
+			}
+			batteryPresent = true
+			if c, err := strconv.Atoi(attrs["capacity"]); err == nil && (batteryCapacity < 0 || c < batteryCapacity) {
+				batteryCapacity = c
+			}
+		}
+	}
+
+	status := map[string]string{
+		"ac_online":       strconv.FormatBool(acOnline),
+		"battery_present": strconv.FormatBool(batteryPresent),
+	}
+	if batteryCapacity >= 0 {
+		status["battery_capacity"] = strconv.Itoa(batteryCapacity)
+	}
+	return status
+}
+
+// detectThermalZones detects the thermal zones of the system, including
+// their current temperature and the temperatures of their trip points.
+func detectThermalZones() ([]nfdv1alpha1.InstanceFeature, error) {
+	basePath := hostpath.SysfsDir.Path("class/thermal")
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := make([]nfdv1alpha1.InstanceFeature, 0, len(entries))
+	for _, e := range entries {
+		if !thermalZoneRe.MatchString(e.Name()) {
+			continue
+		}
+		zonePath := filepath.Join(basePath, e.Name())
+		attrs := map[string]string{"name": e.Name()}
+		for _, attr := range []string{"type", "temp", "policy"} {
+			if v, err := utils.ReadSysfsAttr(zonePath, attr); err == nil {
+				attrs[attr] = v
+			}
+		}
+		for k, v := range readTripPoints(zonePath) {
+			attrs["trip."+k] = strconv.Itoa(v)
+		}
+		info = append(info, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return info, nil
+}
+
+// readTripPoints returns the lowest temperature of each trip point type
+// (e.g. "passive", "hot", "critical") of a thermal zone.
+func readTripPoints(zonePath string) map[string]int {
+	trips := make(map[string]int)
+
+	files, err := os.ReadDir(zonePath)
+	if err != nil {
+		return trips
+	}
+	for _, f := range files {
+		m := tripPointRe.FindStringSubmatch(f.Name())
+		if m == nil {
+			continue
+		}
+		tripType, err := utils.ReadSysfsAttr(zonePath, f.Name())
+		if err != nil {
+			continue
+		}
+		t, err := utils.ReadSysfsAttr(zonePath, "trip_point_"+m[1]+"_temp")
+		if err != nil {
+			continue
+		}
+		temp, err := strconv.Atoi(t)
+		if err != nil {
+			continue
+		}
+		if cur, ok := trips[tripType]; !ok || temp < cur {
+			trips[tripType] = temp
+		}
+	}
+	return trips
+}
+
+// detectPowercap detects the power capping zones of the system, e.g. the
+// RAPL (Running Average Power Limit) domains of the CPU packages.
+func detectPowercap() ([]nfdv1alpha1.InstanceFeature, error) {
+	basePath := hostpath.SysfsDir.Path("class/powercap")
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := make([]nfdv1alpha1.InstanceFeature, 0, len(entries))
+	for _, e := range entries {
+		zonePath := filepath.Join(basePath, e.Name())
+		// Control types (e.g. intel-rapl) do not have a name
+		zoneName, err := utils.ReadSysfsAttr(zonePath, "name")
+		if err != nil {
+			continue
+		}
+		attrs := map[string]string{
+			"name":      e.Name(),
+			"zone_name": zoneName,
+		}
+		for _, attr := range []string{"enabled", "max_power_range_uw"} {
+			if v, err := utils.ReadSysfsAttr(zonePath, attr); err == nil {
+				attrs[attr] = v
+			}
+		}
+
+		files, err := os.ReadDir(zonePath)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			m := constraintRe.FindStringSubmatch(f.Name())
+			if m == nil {
+				continue
+			}
+			name, err := utils.ReadSysfsAttr(zonePath, f.Name())
+			if err != nil {
+				continue
+			}
+			for _, attr := range []string{"power_limit_uw", "max_power_uw", "time_window_us"} {
+				if v, err := utils.ReadSysfsAttr(zonePath, "constraint_"+m[1]+"_"+attr); err == nil {
+					attrs["constraint."+name+"."+attr] = v
+				}
+			}
+		}
+		info = append(info, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return info, nil
+}
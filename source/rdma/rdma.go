@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdma
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Name of this feature source
+const Name = "rdma"
+
+const (
+	// DeviceFeature exposes RDMA devices (HCAs)
+	DeviceFeature = "device"
+	// PortFeature exposes the ports of RDMA devices
+	PortFeature = "port"
+)
+
+// rdmaSource implements the FeatureSource interface.
+type rdmaSource struct {
+	features *nfdv1alpha1.Features
+}
+
+// Singleton source instance
+var (
+	src rdmaSource
+	_   source.FeatureSource = &src
+)
+
+// Name returns an identifier string for this feature source.
+func (s *rdmaSource) Name() string { return Name }
+
+// Discover method of the FeatureSource interface
+func (s *rdmaSource) Discover() error {
+	s.features = nfdv1alpha1.NewFeatures()
+
+	devs, ports, err := detectRdma()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			klog.V(2).InfoS("no RDMA devices found")
+			return nil
+		}
+		return fmt.Errorf("failed to detect RDMA devices: %w", err)
+	}
+	s.features.Instances[DeviceFeature] = nfdv1alpha1.NewInstanceFeatures(devs)
+	s.features.Instances[PortFeature] = nfdv1alpha1.NewInstanceFeatures(ports)
+
+	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
+
+	return nil
+}
+
+// GetFeatures method of the FeatureSource Interface.
+func (s *rdmaSource) GetFeatures() *nfdv1alpha1.Features {
+	if s.features == nil {
+		s.features = nfdv1alpha1.NewFeatures()
+	}
+	return s.features
+}
+
+func init() {
+	source.Register(&src)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdma
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
+func TestRdmaSource(t *testing.T) {
+	assert.Equal(t, src.Name(), Name)
+
+	expectedFeatures := map[string]*nfdv1alpha1.Features{
+		"rootfs-empty": nfdv1alpha1.NewFeatures(),
+		"rootfs-1": &nfdv1alpha1.Features{
+			Flags:      map[string]nfdv1alpha1.FlagFeatureSet{},
+			Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{},
+			Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+				DeviceFeature: nfdv1alpha1.InstanceFeatureSet{
+					Elements: []nfdv1alpha1.InstanceFeature{
+						{
+							Attributes: map[string]string{
+								"name":           "mlx5_0",
+								"node_guid":      "b8ce:f603:0012:3456",
+								"sys_image_guid": "b8ce:f603:0012:3456",
+								"fw_ver":         "16.35.2000",
+								"hca_type":       "MT4119",
+								"board_id":       "MT_0000000012",
+								"node_type":      "1: CA",
+								"vendor":         "15b3",
+								"pci_address":    "0000:3b:00.0",
+								"port_count":     "1",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"name":        "mlx5_1",
+								"node_guid":   "b8ce:f603:0012:3457",
+								"fw_ver":      "16.35.2000",
+								"hca_type":    "MT4119",
+								"node_type":   "1: CA",
+								"vendor":      "15b3",
+								"pci_address": "0000:3b:00.1",
+								"port_count":  "1",
+							},
+						},
+					},
+				},
+				PortFeature: nfdv1alpha1.InstanceFeatureSet{
+					Elements: []nfdv1alpha1.InstanceFeature{
+						{
+							Attributes: map[string]string{
+								"device":     "mlx5_0",
+								"port":       "1",
+								"link_layer": "Ethernet",
+								"state":      "ACTIVE",
+								"phys_state": "LinkUp",
+								"rate":       "100 Gb/sec (4X EDR)",
+								"rate_gbps":  "100",
+								"gid_count":  "2",
+								"netdev":     "enp59s0f0",
+							},
+						},
+						{
+							Attributes: map[string]string{
+								"device":     "mlx5_1",
+								"port":       "1",
+								"link_layer": "InfiniBand",
+								"state":      "DOWN",
+								"phys_state": "Disabled",
+								"rate":       "10 Gb/sec (4X SDR)",
+								"rate_gbps":  "10",
+								"gid_count":  "0",
+								"netdev":     "ib0",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for rootfs, expected := range expectedFeatures {
+		t.Run(rootfs, func(t *testing.T) {
+			hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", rootfs))
+			defer hostpath.SetHostRoot("/")
+
+			testSrc := rdmaSource{}
+			assert.Nil(t, testSrc.Discover())
+			assert.Equal(t, expected, testSrc.GetFeatures())
+		})
+	}
+}
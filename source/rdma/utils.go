@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdma
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// devAttrs is the list of files under /sys/class/infiniband/<dev> that we're reading
+var devAttrs = []string{"node_guid", "sys_image_guid", "fw_ver", "hca_type", "board_id", "node_type"}
+
+// portAttrs is the list of files under /sys/class/infiniband/<dev>/ports/<port> that we're reading
+var portAttrs = []string{"link_layer", "state", "phys_state", "rate"}
+
+// zeroGid is the value of unused entries of the GID table
+const zeroGid = "0000:0000:0000:0000:0000:0000:0000:0000"
+
+// detectRdma detects RDMA devices and their ports.
+func detectRdma() ([]nfdv1alpha1.InstanceFeature, []nfdv1alpha1.InstanceFeature, error) {
+	sysfsBasePath := hostpath.SysfsDir.Path("class/infiniband")
+
+	devices, err := os.ReadDir(sysfsBasePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	devInfo := make([]nfdv1alpha1.InstanceFeature, 0, len(devices))
+	portInfo := make([]nfdv1alpha1.InstanceFeature, 0)
+	for _, device := range devices {
+		devPath := filepath.Join(sysfsBasePath, device.Name())
+
+		ports, err := os.ReadDir(filepath.Join(devPath, "ports"))
+		if err != nil {
+			klog.ErrorS(err, "failed to list RDMA device ports", "deviceName", device.Name())
+			continue
+		}
+
+		dev := readRdmaDevInfo(devPath)
+		dev.Attributes["port_count"] = strconv.Itoa(len(ports))
+		devInfo = append(devInfo, *dev)
+
+		for _, port := range ports {
+			portInfo = append(portInfo, *readRdmaPortInfo(devPath, port.Name()))
+		}
+	}
+
+	return devInfo, portInfo, nil
+}
+
+// readRdmaDevInfo reads the information of one RDMA device.
+func readRdmaDevInfo(devPath string) *nfdv1alpha1.InstanceFeature {
+	attrs := map[string]string{"name": filepath.Base(devPath)}
+	for _, attr := range devAttrs {
+		if v, err := utils.ReadSysfsAttr(devPath, attr); err == nil {
+			attrs[attr] = v
+		}
+	}
+	if v, err := utils.ReadSysfsAttr(devPath, "device/vendor"); err == nil {
+		attrs["vendor"] = strings.TrimPrefix(v, "0x")
+	}
+	if subsystem, err := os.Readlink(filepath.Join(devPath, "device/subsystem")); err == nil && filepath.Base(subsystem) == "pci" {
+		if dev, err := os.Readlink(filepath.Join(devPath, "device")); err == nil {
+			attrs["pci_address"] = filepath.Base(dev)
+		}
+	}
+	return nfdv1alpha1.NewInstanceFeature(attrs)
+}
+
+// readRdmaPortInfo reads the information of one port of an RDMA device.
+func readRdmaPortInfo(devPath, port string) *nfdv1alpha1.InstanceFeature {
+	portPath := filepath.Join(devPath, "ports", port)
+	attrs := map[string]string{
+		"device": filepath.Base(devPath),
+		"port":   port,
+	}
+	for _, attr := range portAttrs {
+		v, err := utils.ReadSysfsAttr(portPath, attr)
+		if err != nil {
+			continue
+		}
+		switch attr {
+		case "state", "phys_state":
+			// Strip the numerical value, e.g. "4: ACTIVE"
+			if _, s, ok := strings.Cut(v, ":"); ok {
+				v = strings.TrimSpace(s)
+			}
+		case "rate":
+			// Rate is of format "100 Gb/sec (4X EDR)"
+			if f := strings.Fields(v); len(f) > 0 {
+				attrs["rate_gbps"] = f[0]
+			}
+		}
+		attrs[attr] = v
+	}
+
+	if n, err := countGids(filepath.Join(portPath, "gids")); err == nil {
+		attrs["gid_count"] = strconv.Itoa(n)
+	}
+
+	// RoCE ports have the netdev of each GID entry in gid_attrs. Fall back to
+	// the netdevs of the parent device otherwise (e.g. IPoIB).
+	if v, err := utils.ReadSysfsAttr(portPath, "gid_attrs/ndevs/0"); err == nil && v != "" {
+		attrs["netdev"] = v
+	} else if netdevs, err := os.ReadDir(filepath.Join(devPath, "device/net")); err == nil && len(netdevs) > 0 {
+		attrs["netdev"] = netdevs[0].Name()
+	}
+
+	return nfdv1alpha1.NewInstanceFeature(attrs)
+}
+
+// countGids returns the number of populated entries in a GID table.
+func countGids(gidsPath string) (int, error) {
+	gids, err := os.ReadDir(gidsPath)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, gid := range gids {
+		if v, err := utils.ReadSysfsAttr(gidsPath, gid.Name()); err == nil && v != zeroGid {
+			n++
+		}
+	}
+	return n, nil
+}
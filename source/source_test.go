@@ -25,16 +25,21 @@ import (
 	source "sigs.k8s.io/node-feature-discovery/source"
 
 	// Register all source packages
+	_ "sigs.k8s.io/node-feature-discovery/source/accelerator"
 	_ "sigs.k8s.io/node-feature-discovery/source/cpu"
 	_ "sigs.k8s.io/node-feature-discovery/source/custom"
+	_ "sigs.k8s.io/node-feature-discovery/source/deviceplugin"
 	_ "sigs.k8s.io/node-feature-discovery/source/fake"
 	_ "sigs.k8s.io/node-feature-discovery/source/kernel"
 	_ "sigs.k8s.io/node-feature-discovery/source/local"
 	_ "sigs.k8s.io/node-feature-discovery/source/memory"
 	_ "sigs.k8s.io/node-feature-discovery/source/network"
 	_ "sigs.k8s.io/node-feature-discovery/source/pci"
+	_ "sigs.k8s.io/node-feature-discovery/source/power"
+	_ "sigs.k8s.io/node-feature-discovery/source/rdma"
 	_ "sigs.k8s.io/node-feature-discovery/source/storage"
 	_ "sigs.k8s.io/node-feature-discovery/source/system"
+	_ "sigs.k8s.io/node-feature-discovery/source/time"
 	_ "sigs.k8s.io/node-feature-discovery/source/usb"
 )
 
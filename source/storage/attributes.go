@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+)
+
+// errAttrNotAvailable is returned by attribute readers when the attribute
+// does not apply to the block device.
+var errAttrNotAvailable = errors.New("attribute not available")
+
+// blockAttrReader reads one attribute of the block device whose sysfs
+// directory is given as the argument.
+type blockAttrReader func(devPath string, mounts mountedDevs) (string, error)
+
+// blockAttrReaders contains all supported block device attributes.
+var blockAttrReaders = map[string]blockAttrReader{
+	"dax":                  sysfsAttr("queue/dax"),
+	"rotational":           sysfsAttr("queue/rotational"),
+	"nr_zones":             sysfsAttr("queue/nr_zones"),
+	"zoned":                sysfsAttr("queue/zoned"),
+	"logical_block_size":   sysfsAttr("queue/logical_block_size"),
+	"physical_block_size":  sysfsAttr("queue/physical_block_size"),
+	"removable":            sysfsAttr("removable"),
+	"model":                sysfsAttr("device/model"),
+	"vendor":               sysfsAttr("device/vendor"),
+	"serial":               sysfsAttr("device/serial"),
+	"firmware_rev":         readFirmwareRev,
+	"size":                 readSize,
+	"transport":            readTransport,
+	"nvme_namespace_count": readNvmeNamespaceCount,
+	"nvme_transport":       readNvmeTransport,
+	"partitioned":          readPartitioned,
+	"mounted":              readMounted,
+	"in_use":               readInUse,
+}
+
+// sectorSize is the unit of the size attribute in sysfs, independent of the
+// block size of the device.
+const sectorSize = 512
+
+// transportPatterns maps sysfs device paths to the transport of the block
+// device. The patterns are matched in order against the resolved sysfs path
+// of the device.
+var transportPatterns = []struct {
+	re        *regexp.Regexp
+	transport string
+}{
+	{regexp.MustCompile(`/nvme/`), "nvme"},
+	{regexp.MustCompile(`/virtio\d+/`), "virtio"},
+	{regexp.MustCompile(`/usb\d+/`), "usb"},
+	{regexp.MustCompile(`/end_device-`), "sas"},
+	{regexp.MustCompile(`/ata\d+/`), "sata"},
+	{regexp.MustCompile(`/mmc_host/`), "mmc"},
+	{regexp.MustCompile(`/host\d+/`), "scsi"},
+}
+
+// nvmeNamespaceRe matches the name of the namespaces of an NVMe controller.
+var nvmeNamespaceRe = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// sysfsAttr returns a reader for a plain sysfs attribute file.
+func sysfsAttr(file string) blockAttrReader {
+	return func(devPath string, _ mountedDevs) (string, error) {
+		return utils.ReadSysfsAttr(devPath, file)
+	}
+}
+
+// readSize returns the size of the block device in bytes.
+func readSize(devPath string, _ mountedDevs) (string, error) {
+	s, err := utils.ReadSysfsAttr(devPath, "size")
+	if err != nil {
+		return "", err
+	}
+	sectors, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(sectors*sectorSize, 10), nil
+}
+
+// readTransport returns the transport (bus) through which the block device
+// is attached to the system, based on its location in the sysfs device tree.
+func readTransport(devPath string, _ mountedDevs) (string, error) {
+	target, err := os.Readlink(devPath)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range transportPatterns {
+		if p.re.MatchString(target) {
+			return p.transport, nil
+		}
+	}
+	return "", errAttrNotAvailable
+}
+
+// readFirmwareRev returns the firmware revision of the block device.
+func readFirmwareRev(devPath string, _ mountedDevs) (string, error) {
+	// NVMe controllers have "firmware_rev" and SCSI devices have "rev"
+	for _, f := range []string{"device/firmware_rev", "device/rev"} {
+		if v, err := utils.ReadSysfsAttr(devPath, f); err == nil {
+			return v, nil
+		}
+	}
+	return "", errAttrNotAvailable
+}
+
+// readNvmeNamespaceCount returns the number of namespaces of the NVMe
+// controller of the block device.
+func readNvmeNamespaceCount(devPath string, _ mountedDevs) (string, error) {
+	if !isNvme(devPath) {
+		return "", errAttrNotAvailable
+	}
+	entries, err := os.ReadDir(filepath.Join(devPath, "device"))
+	if err != nil {
+		return "", err
+	}
+	count := 0
+	for _, e := range entries {
+		if nvmeNamespaceRe.MatchString(e.Name()) {
+			count++
+		}
+	}
+	return strconv.Itoa(count), nil
+}
+
+// readNvmeTransport returns the transport of the NVMe controller of the block
+// device, e.g. "pcie", "tcp" or "rdma".
+func readNvmeTransport(devPath string, _ mountedDevs) (string, error) {
+	if !isNvme(devPath) {
+		return "", errAttrNotAvailable
+	}
+	return utils.ReadSysfsAttr(devPath, "device/transport")
+}
+
+// readPartitioned returns "true" if the block device has partitions.
+func readPartitioned(devPath string, _ mountedDevs) (string, error) {
+	parts, err := getPartitions(devPath)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatBool(len(parts) > 0), nil
+}
+
+// readMounted returns "true" if the block device or any of its partitions is
+// mounted.
+func readMounted(devPath string, mounts mountedDevs) (string, error) {
+	if mounts == nil {
+		return "", errAttrNotAvailable
+	}
+	mounted, err := isMounted(devPath, mounts)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatBool(mounted), nil
+}
+
+// readInUse returns "true" if the block device is in use, i.e. it has
+// partitions, it is mounted or it is held by another block device (e.g.
+// device mapper or software RAID).
+func readInUse(devPath string, mounts mountedDevs) (string, error) {
+	if mounts == nil {
+		return "", errAttrNotAvailable
+	}
+	parts, err := getPartitions(devPath)
+	if err != nil {
+		return "", err
+	}
+	mounted, err := isMounted(devPath, mounts)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatBool(len(parts) > 0 || mounted || hasHolders(devPath)), nil
+}
+
+// isNvme returns true if the block device is an NVMe namespace.
+func isNvme(devPath string) bool {
+	t, err := readTransport(devPath, nil)
+	return err == nil && t == "nvme"
+}
+
+// getPartitions returns the sysfs paths of the partitions of the block
+// device.
+func getPartitions(devPath string) ([]string, error) {
+	entries, err := os.ReadDir(devPath)
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	for _, e := range entries {
+		p := filepath.Join(devPath, e.Name())
+		if _, err := os.Stat(filepath.Join(p, "partition")); err == nil {
+			parts = append(parts, p)
+		}
+	}
+	return parts, nil
+}
+
+// isMounted returns true if the block device or any of its partitions is
+// mounted.
+func isMounted(devPath string, mounts mountedDevs) (bool, error) {
+	parts, err := getPartitions(devPath)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range append([]string{devPath}, parts...) {
+		if devID, err := utils.ReadSysfsAttr(p, "dev"); err == nil {
+			if _, ok := mounts[devID]; ok {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// hasHolders returns true if some other block device is stacked on top of
+// the block device or any of its partitions.
+func hasHolders(devPath string) bool {
+	parts, _ := getPartitions(devPath)
+	for _, p := range append([]string{devPath}, parts...) {
+		if entries, err := os.ReadDir(filepath.Join(p, "holders")); err == nil && len(entries) > 0 {
+			return true
+		}
+	}
+	return false
+}
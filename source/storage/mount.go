@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// statfs is used for getting filesystem statistics, replaceable in tests.
+var statfs = unix.Statfs
+
+// detectMounts returns the mounts of the host whose mount point matches any
+// of the given patterns.
+func detectMounts(patterns []string) ([]nfdv1alpha1.InstanceFeature, error) {
+	if len(patterns) == 0 {
+		return []nfdv1alpha1.InstanceFeature{}, nil
+	}
+
+	mounts, err := readHostMountInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	// A mount point may be mounted over multiple times, the last one is the
+	// one visible
+	idx := make(map[string]int)
+	selected := make([]mountInfo, 0)
+	for _, m := range mounts {
+		if !matchMountPoint(m.MountPoint, patterns) {
+			continue
+		}
+		if i, ok := idx[m.MountPoint]; ok {
+			selected[i] = m
+		} else {
+			idx[m.MountPoint] = len(selected)
+			selected = append(selected, m)
+		}
+	}
+
+	info := make([]nfdv1alpha1.InstanceFeature, 0, len(selected))
+	for _, m := range selected {
+		info = append(info, *readMountInfo(m))
+	}
+	return info, nil
+}
+
+// matchMountPoint returns true if the mount point matches any of the given
+// glob patterns.
+func matchMountPoint(mountPoint string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, err := filepath.Match(p, mountPoint); err != nil {
+			klog.ErrorS(err, "invalid mount point pattern", "pattern", p)
+		} else if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func readMountInfo(m mountInfo) *nfdv1alpha1.InstanceFeature {
+	attrs := map[string]string{
+		"mountpoint":    m.MountPoint,
+		"fstype":        m.FsType,
+		"source":        m.Source,
+		"options":       m.Options,
+		"super_options": m.SuperOptions,
+	}
+
+	// The mount points of the host are accessed through the root directory
+	// of the host init process, which requires sufficient privileges
+	var st unix.Statfs_t
+	if err := statfs(hostpath.ProcfsDir.Path("1/root", m.MountPoint), &st); err == nil {
+		attrs["size"] = strconv.FormatUint(st.Blocks*uint64(st.Bsize), 10)
+		attrs["free"] = strconv.FormatUint(st.Bavail*uint64(st.Bsize), 10)
+	} else {
+		klog.V(3).InfoS("failed to get filesystem statistics", "mountPoint", m.MountPoint, "err", err)
+		// Fall back to the size mount option of tmpfs
+		if m.FsType == "tmpfs" {
+			if size, ok := parseTmpfsSize(m.SuperOptions); ok {
+				attrs["size"] = strconv.FormatUint(size, 10)
+			}
+		}
+	}
+
+	return nfdv1alpha1.NewInstanceFeature(attrs)
+}
+
+// parseTmpfsSize parses the size option (e.g. "size=1048576k") of a tmpfs
+// mount into bytes.
+func parseTmpfsSize(options string) (uint64, bool) {
+	for _, opt := range strings.Split(options, ",") {
+		v, ok := strings.CutPrefix(opt, "size=")
+		if !ok || v == "" {
+			continue
+		}
+		mult := uint64(1)
+		switch v[len(v)-1] {
+		case 'k', 'K':
+			mult = 1 << 10
+		case 'm', 'M':
+			mult = 1 << 20
+		case 'g', 'G':
+			mult = 1 << 30
+		}
+		if mult != 1 {
+			v = v[:len(v)-1]
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return n * mult, true
+	}
+	return 0, false
+}
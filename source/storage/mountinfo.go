@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// mountInfo describes one entry of /proc/<pid>/mountinfo.
+type mountInfo struct {
+	// DevID is the "major:minor" number of the mounted device
+	DevID        string
+	Root         string
+	MountPoint   string
+	Options      string
+	FsType       string
+	Source       string
+	SuperOptions string
+}
+
+// mountedDevs is a set of "major:minor" numbers of mounted devices.
+type mountedDevs map[string]struct{}
+
+// readHostMountInfo returns the mounts of the host. The mountinfo of the init
+// process is used, as /proc/self of the host procfs would refer to the mount
+// namespace of nfd-worker itself when running inside a container.
+func readHostMountInfo() ([]mountInfo, error) {
+	f, err := os.Open(hostpath.ProcfsDir.Path("1/mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseMountInfo(f)
+}
+
+// getMountedDevs returns the set of block devices mounted on the host.
+func getMountedDevs() (mountedDevs, error) {
+	mounts, err := readHostMountInfo()
+	if err != nil {
+		return nil, err
+	}
+	devs := make(mountedDevs, len(mounts))
+	for _, m := range mounts {
+		devs[m.DevID] = struct{}{}
+	}
+	return devs, nil
+}
+
+// parseMountInfo parses the content of a mountinfo file. The format of one
+// line is:
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+//
+// i.e. the optional fields are terminated by a single hyphen, after which the
+// filesystem type, mount source and super block options follow.
+func parseMountInfo(r io.Reader) ([]mountInfo, error) {
+	var mounts []mountInfo
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || len(fields) < sep+4 {
+			return nil, fmt.Errorf("invalid mountinfo line %q", s.Text())
+		}
+		mounts = append(mounts, mountInfo{
+			DevID:        fields[2],
+			Root:         unescapeMountInfo(fields[3]),
+			MountPoint:   unescapeMountInfo(fields[4]),
+			Options:      fields[5],
+			FsType:       fields[sep+1],
+			Source:       unescapeMountInfo(fields[sep+2]),
+			SuperOptions: fields[sep+3],
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// unescapeMountInfo decodes the octal escapes (e.g. "\040" for space) used in
+// the path fields of mountinfo.
+func unescapeMountInfo(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
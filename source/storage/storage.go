@@ -17,10 +17,12 @@ limitations under the License.
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"strings"
+	"syscall"
 
 	"k8s.io/klog/v2"
 
@@ -33,26 +35,65 @@ import (
 // Name of this feature source
 const Name = "storage"
 
-const BlockFeature = "block"
+const (
+	// BlockFeature exposes block devices
+	BlockFeature = "block"
+	// MountFeature exposes mounted filesystems
+	MountFeature = "mount"
+)
+
+// Config holds the configuration parameters of this source.
+type Config struct {
+	// BlockAttributes is the list of attributes to discover for block
+	// devices.
+	BlockAttributes []string `json:"blockAttributes,omitempty"`
+	// MountPoints is the list of mount points (glob patterns) to discover.
+	MountPoints []string `json:"mountPoints,omitempty"`
+}
+
+// newDefaultConfig returns a new config with pre-populated defaults
+func newDefaultConfig() *Config {
+	return &Config{
+		BlockAttributes: []string{"dax", "rotational", "nr_zones", "zoned",
+			"size", "logical_block_size", "physical_block_size", "removable", "model", "vendor",
+			"transport", "nvme_namespace_count", "nvme_transport", "partitioned", "mounted", "in_use"},
+		MountPoints: []string{},
+	}
+}
 
-// storageSource implements the FeatureSource and LabelSource interfaces.
+// storageSource implements the FeatureSource, LabelSource and ConfigurableSource interfaces.
 type storageSource struct {
+	config   *Config
 	features *nfdv1alpha1.Features
 }
 
 // Singleton source instance
 var (
-	src storageSource
-	_   source.FeatureSource = &src
-	_   source.LabelSource   = &src
+	src                           = storageSource{config: newDefaultConfig()}
+	_   source.FeatureSource      = &src
+	_   source.LabelSource        = &src
+	_   source.ConfigurableSource = &src
 )
 
-// queueAttrs is the list of files under /sys/block/<dev>/queue that we're trying to read
-var queueAttrs = []string{"dax", "rotational", "nr_zones", "zoned"}
-
 // Name returns an identifier string for this feature source.
 func (s *storageSource) Name() string { return Name }
 
+// NewConfig method of the LabelSource interface
+func (s *storageSource) NewConfig() source.Config { return newDefaultConfig() }
+
+// GetConfig method of the LabelSource interface
+func (s *storageSource) GetConfig() source.Config { return s.config }
+
+// SetConfig method of the LabelSource interface
+func (s *storageSource) SetConfig(conf source.Config) {
+	switch v := conf.(type) {
+	case *Config:
+		s.config = v
+	default:
+		panic(fmt.Sprintf("invalid config type: %T", conf))
+	}
+}
+
 // Priority method of the LabelSource interface
 func (s *storageSource) Priority() int { return 0 }
 
@@ -64,7 +105,11 @@ func (s *storageSource) GetLabels() (source.FeatureLabels, error) {
 	for _, dev := range features.Instances[BlockFeature].Elements {
 		if dev.Attributes["rotational"] == "0" {
 			labels["nonrotationaldisk"] = true
-			break
+		}
+		// The name is always available, unlike the configurable transport
+		// attribute
+		if nvmeNamespaceRe.MatchString(dev.Attributes["name"]) || dev.Attributes["transport"] == "nvme" {
+			labels["nvme.present"] = true
 		}
 	}
 
@@ -75,12 +120,19 @@ func (s *storageSource) GetLabels() (source.FeatureLabels, error) {
 func (s *storageSource) Discover() error {
 	s.features = nfdv1alpha1.NewFeatures()
 
-	devs, err := detectBlock()
+	devs, err := detectBlock(s.config.BlockAttributes)
 	if err != nil {
 		return fmt.Errorf("failed to detect block devices: %w", err)
 	}
 	s.features.Instances[BlockFeature] = nfdv1alpha1.InstanceFeatureSet{Elements: devs}
 
+	mounts, err := detectMounts(s.config.MountPoints)
+	if err != nil {
+		klog.ErrorS(err, "failed to detect mounts")
+	} else {
+		s.features.Instances[MountFeature] = nfdv1alpha1.InstanceFeatureSet{Elements: mounts}
+	}
+
 	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
 
 	return nil
@@ -94,7 +146,7 @@ func (s *storageSource) GetFeatures() *nfdv1alpha1.Features {
 	return s.features
 }
 
-func detectBlock() ([]nfdv1alpha1.InstanceFeature, error) {
+func detectBlock(attrNames []string) ([]nfdv1alpha1.InstanceFeature, error) {
 	sysfsBasePath := hostpath.SysfsDir.Path("block")
 
 	blockdevices, err := os.ReadDir(sysfsBasePath)
@@ -102,24 +154,42 @@ func detectBlock() ([]nfdv1alpha1.InstanceFeature, error) {
 		return nil, fmt.Errorf("failed to list block devices: %w", err)
 	}
 
+	// Mounted devices are only needed by some of the attributes
+	var mounts mountedDevs
+	for _, attrName := range attrNames {
+		if attrName == "mounted" || attrName == "in_use" {
+			if mounts, err = getMountedDevs(); err != nil {
+				klog.ErrorS(err, "failed to read mounted devices")
+			}
+			break
+		}
+	}
+
 	// Iterate over devices
 	info := make([]nfdv1alpha1.InstanceFeature, 0, len(blockdevices))
 	for _, device := range blockdevices {
-		info = append(info, *readBlockDevQueueInfo(filepath.Join(sysfsBasePath, device.Name())))
+		info = append(info, *readBlockDevInfo(filepath.Join(sysfsBasePath, device.Name()), attrNames, mounts))
 	}
 
 	return info, nil
 }
 
-func readBlockDevQueueInfo(path string) *nfdv1alpha1.InstanceFeature {
+func readBlockDevInfo(path string, attrNames []string, mounts mountedDevs) *nfdv1alpha1.InstanceFeature {
 	attrs := map[string]string{"name": filepath.Base(path)}
-	for _, attrName := range queueAttrs {
-		data, err := os.ReadFile(filepath.Join(path, "queue", attrName))
+	for _, attrName := range attrNames {
+		reader, ok := blockAttrReaders[attrName]
+		if !ok {
+			klog.InfoS("ignoring unknown block device attribute", "attributeName", attrName)
+			continue
+		}
+		val, err := reader(path, mounts)
 		if err != nil {
-			klog.V(3).ErrorS(err, "failed to read block device queue attribute", "attributeName", attrName)
+			if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, errAttrNotAvailable) {
+				klog.V(3).ErrorS(err, "failed to read block device attribute", "attributeName", attrName)
+			}
 			continue
 		}
-		attrs[attrName] = strings.TrimSpace(string(data))
+		attrs[attrName] = val
 	}
 	return nfdv1alpha1.NewInstanceFeature(attrs)
 }
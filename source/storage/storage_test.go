@@ -17,11 +17,26 @@ limitations under the License.
 package storage
 
 import (
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+	"sigs.k8s.io/node-feature-discovery/source"
 )
 
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
 func TestStorageSource(t *testing.T) {
 	assert.Equal(t, src.Name(), Name)
 
@@ -33,3 +48,195 @@ func TestStorageSource(t *testing.T) {
 	assert.Empty(t, l)
 
 }
+
+func TestStorageSourceRootfs(t *testing.T) {
+	hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", "rootfs-1"))
+	defer hostpath.SetHostRoot("/")
+
+	queueAttrs := func(rotational, physicalBlockSize string) map[string]string {
+		return map[string]string{
+			"dax":                 "0",
+			"rotational":          rotational,
+			"nr_zones":            "0",
+			"zoned":               "none",
+			"logical_block_size":  "512",
+			"physical_block_size": physicalBlockSize,
+			"removable":           "0",
+		}
+	}
+	newDev := func(name string, base map[string]string, attrs map[string]string) nfdv1alpha1.InstanceFeature {
+		a := map[string]string{"name": name}
+		for k, v := range base {
+			a[k] = v
+		}
+		for k, v := range attrs {
+			a[k] = v
+		}
+		return *nfdv1alpha1.NewInstanceFeature(a)
+	}
+
+	tests := []struct {
+		name           string
+		config         *Config
+		expectedDevs   []nfdv1alpha1.InstanceFeature
+		expectedLabels source.FeatureLabels
+	}{
+		{
+			name: "default config",
+			expectedDevs: []nfdv1alpha1.InstanceFeature{
+				newDev("loop0", queueAttrs("1", "512"), map[string]string{
+					"size":        "0",
+					"partitioned": "false",
+					"mounted":     "false",
+					"in_use":      "false",
+				}),
+				newDev("nvme0n1", queueAttrs("0", "512"), map[string]string{
+					"size":                 "1000204886016",
+					"model":                "Samsung SSD 980 PRO 1TB",
+					"transport":            "nvme",
+					"nvme_namespace_count": "2",
+					"nvme_transport":       "pcie",
+					"partitioned":          "true",
+					"mounted":              "true",
+					"in_use":               "true",
+				}),
+				newDev("nvme0n2", queueAttrs("0", "512"), map[string]string{
+					"size":                 "1073741824",
+					"model":                "Samsung SSD 980 PRO 1TB",
+					"transport":            "nvme",
+					"nvme_namespace_count": "2",
+					"nvme_transport":       "pcie",
+					"partitioned":          "false",
+					"mounted":              "false",
+					"in_use":               "false",
+				}),
+				newDev("sda", queueAttrs("0", "4096"), map[string]string{
+					"size":        "480103981056",
+					"model":       "INTEL SSDSC2KB48",
+					"vendor":      "ATA",
+					"transport":   "sata",
+					"partitioned": "true",
+					"mounted":     "true",
+					"in_use":      "true",
+				}),
+				newDev("sdb", queueAttrs("1", "512"), map[string]string{
+					"size":        "4000787030016",
+					"model":       "ST4000NM0025",
+					"vendor":      "SEAGATE",
+					"transport":   "sas",
+					"partitioned": "false",
+					"mounted":     "false",
+					"in_use":      "true",
+				}),
+			},
+			expectedLabels: source.FeatureLabels{
+				"nonrotationaldisk": true,
+				"nvme.present":      true,
+			},
+		},
+		{
+			name:   "custom config",
+			config: &Config{BlockAttributes: []string{"serial", "firmware_rev", "rotational", "unknown"}},
+			expectedDevs: []nfdv1alpha1.InstanceFeature{
+				newDev("loop0", nil, map[string]string{"rotational": "1"}),
+				newDev("nvme0n1", nil, map[string]string{"rotational": "0", "serial": "S5GXNX0T123456A", "firmware_rev": "5B2QGXA7"}),
+				newDev("nvme0n2", nil, map[string]string{"rotational": "0", "serial": "S5GXNX0T123456A", "firmware_rev": "5B2QGXA7"}),
+				newDev("sda", nil, map[string]string{"rotational": "0", "firmware_rev": "0150"}),
+				newDev("sdb", nil, map[string]string{"rotational": "1"}),
+			},
+			expectedLabels: source.FeatureLabels{
+				"nonrotationaldisk": true,
+				"nvme.present":      true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := storageSource{config: newDefaultConfig()}
+			if tc.config != nil {
+				s.SetConfig(tc.config)
+			}
+
+			assert.Nil(t, s.Discover())
+			assert.Equal(t, tc.expectedDevs, s.GetFeatures().Instances[BlockFeature].Elements)
+
+			l, err := s.GetLabels()
+			assert.Nil(t, err, err)
+			assert.Equal(t, tc.expectedLabels, l)
+		})
+	}
+}
+
+func TestParseMountInfo(t *testing.T) {
+	input := `22 1 8:2 / / rw,relatime shared:1 - ext4 /dev/sda2 rw
+27 22 259:1 / /mnt/disks/local\040ssd rw,noatime - xfs /dev/nvme0n1p1 rw,prjquota
+`
+	mounts, err := parseMountInfo(strings.NewReader(input))
+	assert.Nil(t, err, err)
+	assert.Equal(t, []mountInfo{
+		{DevID: "8:2", Root: "/", MountPoint: "/", Options: "rw,relatime", FsType: "ext4", Source: "/dev/sda2", SuperOptions: "rw"},
+		{DevID: "259:1", Root: "/", MountPoint: "/mnt/disks/local ssd", Options: "rw,noatime", FsType: "xfs", Source: "/dev/nvme0n1p1", SuperOptions: "rw,prjquota"},
+	}, mounts)
+
+	_, err = parseMountInfo(strings.NewReader("22 1 8:2 / / rw,relatime shared:1\n"))
+	assert.NotNil(t, err)
+}
+
+func TestStorageMounts(t *testing.T) {
+	hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", "rootfs-1"))
+	defer hostpath.SetHostRoot("/")
+
+	origStatfs := statfs
+	defer func() { statfs = origStatfs }()
+	statfs = func(path string, st *unix.Statfs_t) error {
+		if path != hostpath.ProcfsDir.Path("1/root/mnt/disks/local ssd") {
+			return unix.EACCES
+		}
+		st.Bsize = 4096
+		st.Blocks = 1000
+		st.Bavail = 250
+		return nil
+	}
+
+	// No mount points are discovered by default
+	s := storageSource{config: newDefaultConfig()}
+	assert.Nil(t, s.Discover())
+	assert.Empty(t, s.GetFeatures().Instances[MountFeature].Elements)
+
+	s.SetConfig(&Config{MountPoints: []string{"/", "/dev/shm", "/var/lib/kubelet", "/mnt/disks/*"}})
+	assert.Nil(t, s.Discover())
+	assert.Equal(t, []nfdv1alpha1.InstanceFeature{
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{
+			"mountpoint":    "/",
+			"fstype":        "ext4",
+			"source":        "/dev/sda2",
+			"options":       "rw,relatime",
+			"super_options": "rw",
+		}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{
+			"mountpoint":    "/dev/shm",
+			"fstype":        "tmpfs",
+			"source":        "tmpfs",
+			"options":       "rw,nosuid,nodev",
+			"super_options": "rw,size=1048576k",
+			"size":          "1073741824",
+		}),
+		*nfdv1alpha1.NewInstanceFeature(map[string]string{
+			"mountpoint":    "/mnt/disks/local ssd",
+			"fstype":        "xfs",
+			"source":        "/dev/nvme0n1p1",
+			"options":       "rw,noatime",
+			"super_options": "rw,attr2,inode64,prjquota",
+			"size":          "4096000",
+			"free":          "1024000",
+		}),
+	}, s.GetFeatures().Instances[MountFeature].Elements)
+
+	// Only the last one of stacked mounts is advertised
+	s.SetConfig(&Config{MountPoints: []string{"/boot", "/proc"}})
+	assert.Nil(t, s.Discover())
+	mounts := s.GetFeatures().Instances[MountFeature].Elements
+	assert.Len(t, mounts, 2)
+	assert.Equal(t, "autofs", mounts[0].Attributes["fstype"])
+}
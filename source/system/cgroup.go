@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// cgroupControllers is the list of cgroup controllers that are always
+// advertised, either as "true" or "false".
+var cgroupControllers = []string{"cpu", "cpuset", "hugetlb", "io", "memory", "misc", "pids", "rdma"}
+
+// discoverCgroup detects the cgroup hierarchy of the host, i.e. the cgroup
+// version, enabled controllers, capacity of the misc controller resources
+// and availability of PSI (Pressure Stall Information).
+func discoverCgroup() (map[string]string, error) {
+	attrs := make(map[string]string)
+
+	cgroupDir := hostpath.SysfsDir.Path("fs/cgroup")
+	if _, err := os.Stat(cgroupDir); err != nil {
+		return nil, fmt.Errorf("cgroup filesystem not found: %w", err)
+	}
+
+	var controllers []string
+	var err error
+	switch {
+	case pathExists(hostpath.SysfsDir.Path("fs/cgroup/cgroup.controllers")):
+		attrs["version"] = "v2"
+		controllers, err = readCgroupV2Controllers(hostpath.SysfsDir.Path("fs/cgroup/cgroup.controllers"))
+	case pathExists(hostpath.SysfsDir.Path("fs/cgroup/unified/cgroup.controllers")):
+		attrs["version"] = "hybrid"
+		controllers, err = readCgroupV1Controllers(hostpath.ProcfsDir.Path("cgroups"))
+	default:
+		attrs["version"] = "v1"
+		controllers, err = readCgroupV1Controllers(hostpath.ProcfsDir.Path("cgroups"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cgroup controllers: %w", err)
+	}
+
+	for _, c := range cgroupControllers {
+		attrs["controller."+c] = "false"
+	}
+	for _, c := range controllers {
+		attrs["controller."+c] = "true"
+	}
+
+	if capacity, err := utils.GetCgroupMiscCapacity(); err == nil {
+		for res, v := range capacity {
+			attrs["misc."+res+".capacity"] = strconv.FormatInt(v, 10)
+		}
+	}
+
+	attrs["psi"] = strconv.FormatBool(pathExists(hostpath.ProcfsDir.Path("pressure/cpu")))
+
+	return attrs, nil
+}
+
+// pathExists returns true if the given path exists.
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readCgroupV2Controllers returns the controllers listed in a
+// cgroup.controllers file of the unified hierarchy.
+func readCgroupV2Controllers(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// readCgroupV1Controllers returns the enabled controllers listed in
+// /proc/cgroups.
+func readCgroupV1Controllers(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var controllers []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// Format: "#subsys_name hierarchy num_cgroups enabled"
+		fields := strings.Fields(s.Text())
+		if len(fields) != 4 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[3] == "1" {
+			controllers = append(controllers, fields[0])
+		}
+	}
+	return controllers, s.Err()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// secureBootEfiVar is the name of the EFI variable holding the Secure Boot
+// state, i.e. SecureBoot-<EFI_GLOBAL_VARIABLE GUID>.
+const secureBootEfiVar = "SecureBoot-8be4df61-93ca-11d2-aa0d-00e098032b8c"
+
+// lockdownModeRe matches the active mode in the kernel lockdown file, which is
+// enclosed in brackets.
+var lockdownModeRe = regexp.MustCompile(`\[(\w+)\]`)
+
+// discoverPlatform detects firmware and platform security related features of
+// the host: UEFI boot, Secure Boot state, TPM device, kernel lockdown mode and
+// active Linux Security Modules.
+func discoverPlatform() map[string]string {
+	attrs := make(map[string]string)
+
+	efi := pathExists(hostpath.SysfsDir.Path("firmware/efi"))
+	attrs["efi"] = strconv.FormatBool(efi)
+	if efi {
+		attrs["secureboot.enabled"] = strconv.FormatBool(secureBootEnabled())
+	}
+
+	for k, v := range discoverTpm() {
+		attrs["tpm."+k] = v
+	}
+
+	if mode, err := readLockdownMode(); err == nil {
+		attrs["lockdown"] = mode
+	}
+
+	if lsms, err := utils.GetActiveLsms(); err == nil {
+		for lsm := range lsms {
+			attrs["lsm."+lsm] = "true"
+		}
+	}
+
+	if enabled, err := os.ReadFile(hostpath.SysfsDir.Path("module/apparmor/parameters/enabled")); err == nil {
+		attrs["apparmor.enabled"] = strconv.FormatBool(strings.TrimSpace(string(enabled)) == "Y")
+	}
+
+	return attrs
+}
+
+// secureBootEnabled reads the SecureBoot EFI variable. The content of the
+// variable file consists of four bytes of attributes followed by the one
+// byte value.
+func secureBootEnabled() bool {
+	data, err := os.ReadFile(hostpath.SysfsDir.Path("firmware/efi/efivars", secureBootEfiVar))
+	if err != nil || len(data) < 5 {
+		return false
+	}
+	return data[4] == 1
+}
+
+// discoverTpm returns the properties of the first TPM device of the system.
+func discoverTpm() map[string]string {
+	attrs := map[string]string{"present": "false"}
+
+	devs, err := filepath.Glob(hostpath.SysfsDir.Path("class/tpm/tpm*"))
+	if err != nil || len(devs) == 0 {
+		return attrs
+	}
+	sort.Strings(devs)
+	dev := devs[0]
+
+	attrs["present"] = "true"
+	if v, err := os.ReadFile(filepath.Join(dev, "tpm_version_major")); err == nil {
+		attrs["version"] = strings.TrimSpace(string(v))
+	}
+	// The caps file is only available for TPM 1.2 devices
+	if caps, err := os.ReadFile(filepath.Join(dev, "device/caps")); err == nil {
+		for _, line := range strings.Split(string(caps), "\n") {
+			if k, v, ok := strings.Cut(line, ":"); ok && k == "Manufacturer" {
+				attrs["manufacturer"] = strings.TrimPrefix(strings.TrimSpace(v), "0x")
+			} else if ok && k == "TCG version" && attrs["version"] == "" {
+				attrs["version"] = strings.TrimSpace(v)
+			}
+		}
+	}
+	if hid, err := os.ReadFile(filepath.Join(dev, "device/hid")); err == nil {
+		attrs["hid"] = strings.TrimSpace(string(hid))
+	}
+	return attrs
+}
+
+// readLockdownMode returns the active kernel lockdown mode, i.e. the one
+// enclosed in brackets in /sys/kernel/security/lockdown.
+func readLockdownMode() (string, error) {
+	data, err := os.ReadFile(hostpath.SysfsDir.Path("kernel/security/lockdown"))
+	if err != nil {
+		return "", err
+	}
+	if m := lockdownModeRe.FindStringSubmatch(string(data)); m != nil {
+		return m[1], nil
+	}
+	return "", fmt.Errorf("failed to parse kernel lockdown mode from %q", strings.TrimSpace(string(data)))
+}
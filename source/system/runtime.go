@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+const criTimeout = 2 * time.Second
+
+// runtimeConfigFiles contains well-known configuration files of container
+// runtimes, relative to the host /etc directory. They are used to detect
+// runtimes whose CRI socket is not reachable.
+var runtimeConfigFiles = []struct {
+	name  string
+	files []string
+}{
+	{name: "containerd", files: []string{"containerd/config.toml"}},
+	{name: "cri-o", files: []string{"crio/crio.conf", "crio/crio.conf.d"}},
+}
+
+// discoverRuntimes detects the container runtimes installed on the host. The
+// CRI sockets are queried first in order to get the exact runtime version.
+// Runtimes not found that way are detected by their configuration files.
+// Neither the sockets nor the configuration directories are mounted in the
+// default deployments, in which case no runtimes are detected.
+func discoverRuntimes(criSockets []string) []nfdv1alpha1.InstanceFeature {
+	found := make(map[string]struct{})
+	runtimes := make([]nfdv1alpha1.InstanceFeature, 0)
+
+	for _, sock := range criSockets {
+		path := hostpath.RunDir.Path(sock)
+		if !pathExists(path) {
+			continue
+		}
+		ver, err := getCriVersion(path)
+		if err != nil {
+			klog.V(2).InfoS("failed to query container runtime version", "socket", path, "err", err)
+			continue
+		}
+		name := strings.ToLower(ver.RuntimeName)
+		found[name] = struct{}{}
+		runtimes = append(runtimes, *nfdv1alpha1.NewInstanceFeature(map[string]string{
+			"name":        name,
+			"version":     ver.RuntimeVersion,
+			"api_version": ver.RuntimeApiVersion,
+		}))
+	}
+
+	for _, rt := range runtimeConfigFiles {
+		if _, ok := found[rt.name]; ok {
+			continue
+		}
+		for _, f := range rt.files {
+			if pathExists(hostpath.EtcDir.Path(f)) {
+				runtimes = append(runtimes, *nfdv1alpha1.NewInstanceFeature(map[string]string{"name": rt.name}))
+				break
+			}
+		}
+	}
+
+	return runtimes
+}
+
+// getCriVersion queries the version of a container runtime over its CRI
+// socket.
+func getCriVersion(socketPath string) (*runtimeapi.VersionResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), criTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %q: %w", socketPath, err)
+	}
+	defer conn.Close()
+
+	return runtimeapi.NewRuntimeServiceClient(conn).Version(ctx, &runtimeapi.VersionRequest{})
+}
@@ -18,6 +18,7 @@ package system
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
@@ -44,22 +45,60 @@ const (
 	OsReleaseFeature = "osrelease"
 	NameFeature      = "name"
 	DmiIdFeature     = "dmiid"
+	CgroupFeature    = "cgroup"
+	RuntimeFeature   = "runtime"
+	PlatformFeature  = "platform"
 )
 
-// systemSource implements the FeatureSource and LabelSource interfaces.
+// Config holds the configuration parameters of this source.
+type Config struct {
+	// CriSockets is a list of container runtime (CRI) sockets, relative to
+	// the /run directory of the host, to query for runtime information.
+	CriSockets []string `json:"criSockets,omitempty"`
+}
+
+// newDefaultConfig returns a new config with pre-populated defaults
+func newDefaultConfig() *Config {
+	return &Config{
+		CriSockets: []string{
+			"containerd/containerd.sock",
+			"crio/crio.sock",
+		},
+	}
+}
+
+// systemSource implements the FeatureSource, LabelSource and ConfigurableSource interfaces.
 type systemSource struct {
+	config   *Config
 	features *nfdv1alpha1.Features
 }
 
 // Singleton source instance
 var (
-	src systemSource
-	_   source.FeatureSource = &src
-	_   source.LabelSource   = &src
+	src                           = systemSource{config: newDefaultConfig()}
+	_   source.FeatureSource      = &src
+	_   source.LabelSource        = &src
+	_   source.ConfigurableSource = &src
 )
 
 func (s *systemSource) Name() string { return Name }
 
+// NewConfig method of the LabelSource interface
+func (s *systemSource) NewConfig() source.Config { return newDefaultConfig() }
+
+// GetConfig method of the LabelSource interface
+func (s *systemSource) GetConfig() source.Config { return s.config }
+
+// SetConfig method of the LabelSource interface
+func (s *systemSource) SetConfig(conf source.Config) {
+	switch v := conf.(type) {
+	case *Config:
+		s.config = v
+	default:
+		panic(fmt.Sprintf("invalid config type: %T", conf))
+	}
+}
+
 // Priority method of the LabelSource interface
 func (s *systemSource) Priority() int { return 0 }
 
@@ -118,6 +157,19 @@ func (s *systemSource) Discover() error {
 		s.features.Attributes[DmiIdFeature] = nfdv1alpha1.NewAttributeFeatures(dmiAttrs)
 	}
 
+	// Get cgroup information
+	if cgroup, err := discoverCgroup(); err != nil {
+		klog.ErrorS(err, "failed to detect cgroup configuration")
+	} else {
+		s.features.Attributes[CgroupFeature] = nfdv1alpha1.NewAttributeFeatures(cgroup)
+	}
+
+	// Get firmware and platform security information
+	s.features.Attributes[PlatformFeature] = nfdv1alpha1.NewAttributeFeatures(discoverPlatform())
+
+	// Detect container runtimes
+	s.features.Instances[RuntimeFeature] = nfdv1alpha1.NewInstanceFeatures(discoverRuntimes(s.config.CriSockets))
+
 	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
 
 	return nil
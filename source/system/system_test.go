@@ -17,11 +17,23 @@ limitations under the License.
 package system
 
 import (
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
 )
 
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
 func TestSystemSource(t *testing.T) {
 	assert.Equal(t, src.Name(), Name)
 
@@ -33,3 +45,109 @@ func TestSystemSource(t *testing.T) {
 	assert.Empty(t, l)
 
 }
+
+func TestCgroupAndRuntime(t *testing.T) {
+	defer hostpath.SetHostRoot("/")
+
+	tests := []struct {
+		rootfs           string
+		expectedCgroup   map[string]string
+		expectedRuntimes []nfdv1alpha1.InstanceFeature
+	}{
+		{
+			rootfs: "rootfs-cgroupv2",
+			expectedCgroup: map[string]string{
+				"version":              "v2",
+				"controller.cpu":       "true",
+				"controller.cpuset":    "true",
+				"controller.hugetlb":   "true",
+				"controller.io":        "true",
+				"controller.memory":    "true",
+				"controller.misc":      "true",
+				"controller.pids":      "true",
+				"controller.rdma":      "true",
+				"misc.sev.capacity":    "509",
+				"misc.sev_es.capacity": "10",
+				"psi":                  "true",
+			},
+			expectedRuntimes: []nfdv1alpha1.InstanceFeature{
+				*nfdv1alpha1.NewInstanceFeature(map[string]string{"name": "containerd"}),
+			},
+		},
+		{
+			rootfs: "rootfs-cgroupv1",
+			expectedCgroup: map[string]string{
+				"version":            "v1",
+				"controller.blkio":   "true",
+				"controller.cpu":     "true",
+				"controller.cpuacct": "true",
+				"controller.cpuset":  "true",
+				"controller.hugetlb": "false",
+				"controller.io":      "false",
+				"controller.memory":  "true",
+				"controller.misc":    "true",
+				"controller.pids":    "true",
+				"controller.rdma":    "false",
+				"misc.tdx.capacity":  "31",
+				"psi":                "false",
+			},
+			expectedRuntimes: []nfdv1alpha1.InstanceFeature{
+				*nfdv1alpha1.NewInstanceFeature(map[string]string{"name": "cri-o"}),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.rootfs, func(t *testing.T) {
+			hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", tc.rootfs))
+
+			cgroup, err := discoverCgroup()
+			assert.Nil(t, err, err)
+			assert.Equal(t, tc.expectedCgroup, cgroup)
+
+			runtimes := discoverRuntimes(newDefaultConfig().CriSockets)
+			assert.Equal(t, tc.expectedRuntimes, runtimes)
+		})
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	defer hostpath.SetHostRoot("/")
+
+	tests := []struct {
+		rootfs   string
+		expected map[string]string
+	}{
+		{
+			rootfs: "rootfs-platform",
+			expected: map[string]string{
+				"efi":                "true",
+				"secureboot.enabled": "true",
+				"tpm.present":        "true",
+				"tpm.version":        "2",
+				"tpm.hid":            "MSFT0101",
+				"lockdown":           "integrity",
+				"lsm.lockdown":       "true",
+				"lsm.capability":     "true",
+				"lsm.landlock":       "true",
+				"lsm.yama":           "true",
+				"lsm.apparmor":       "true",
+				"apparmor.enabled":   "true",
+			},
+		},
+		{
+			rootfs: "rootfs-cgroupv1",
+			expected: map[string]string{
+				"efi":         "false",
+				"tpm.present": "false",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.rootfs, func(t *testing.T) {
+			hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", tc.rootfs))
+			assert.Equal(t, tc.expected, discoverPlatform())
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package time
+
+import (
+	"errors"
+	"io/fs"
+
+	"k8s.io/klog/v2"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+// Name of this feature source
+const Name = "time"
+
+const (
+	// PtpFeature exposes PTP hardware clocks
+	PtpFeature = "ptp"
+	// ClocksourceFeature exposes the clock sources of the kernel
+	ClocksourceFeature = "clocksource"
+	// SyncFeature exposes the synchronization status of the system clock
+	SyncFeature = "sync"
+)
+
+// timeSource implements the FeatureSource and LabelSource interfaces.
+type timeSource struct {
+	features *nfdv1alpha1.Features
+}
+
+// Singleton source instance
+var (
+	src timeSource
+	_   source.FeatureSource = &src
+	_   source.LabelSource   = &src
+)
+
+// Name returns an identifier string for this feature source.
+func (s *timeSource) Name() string { return Name }
+
+// Priority method of the LabelSource interface
+func (s *timeSource) Priority() int { return 0 }
+
+// GetLabels method of the LabelSource interface
+func (s *timeSource) GetLabels() (source.FeatureLabels, error) {
+	labels := source.FeatureLabels{}
+	features := s.GetFeatures()
+
+	// Only clocks of network interfaces are usable for PTP
+	for _, clock := range features.Instances[PtpFeature].Elements {
+		if clock.Attributes["interface"] != "" {
+			labels["ptp.capable"] = true
+			break
+		}
+	}
+	if features.Attributes[SyncFeature].Elements["synchronized"] == "true" {
+		labels["synchronized"] = true
+	}
+
+	return labels, nil
+}
+
+// Discover method of the FeatureSource interface
+func (s *timeSource) Discover() error {
+	s.features = nfdv1alpha1.NewFeatures()
+
+	if clocks, err := detectPtp(); err == nil {
+		s.features.Instances[PtpFeature] = nfdv1alpha1.NewInstanceFeatures(clocks)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		klog.ErrorS(err, "failed to detect PTP clocks")
+	}
+
+	if clocksource, err := detectClocksource(); err == nil {
+		s.features.Attributes[ClocksourceFeature] = nfdv1alpha1.NewAttributeFeatures(clocksource)
+	} else {
+		klog.ErrorS(err, "failed to detect clock source")
+	}
+
+	if sync, err := detectSync(); err == nil {
+		s.features.Attributes[SyncFeature] = nfdv1alpha1.NewAttributeFeatures(sync)
+	} else {
+		klog.ErrorS(err, "failed to detect clock synchronization status")
+	}
+
+	klog.V(3).InfoS("discovered features", "featureSource", s.Name(), "features", utils.DelayedDumper(s.features))
+
+	return nil
+}
+
+// GetFeatures method of the FeatureSource Interface.
+func (s *timeSource) GetFeatures() *nfdv1alpha1.Features {
+	if s.features == nil {
+		s.features = nfdv1alpha1.NewFeatures()
+	}
+	return s.features
+}
+
+func init() {
+	source.Register(&src)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package time
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+	"sigs.k8s.io/node-feature-discovery/source"
+)
+
+var packagePath string
+
+func init() {
+	_, thisFile, _, _ := runtime.Caller(0)
+	packagePath = filepath.Dir(thisFile)
+}
+
+func TestTimeSource(t *testing.T) {
+	assert.Equal(t, src.Name(), Name)
+
+	// Check that GetLabels works with empty features
+	src.features = nil
+	l, err := src.GetLabels()
+
+	assert.Nil(t, err, err)
+	assert.Empty(t, l)
+}
+
+func TestTimeSourceRootfs(t *testing.T) {
+	hostpath.SetHostRoot(filepath.Join(packagePath, "testdata", "rootfs-1"))
+	defer hostpath.SetHostRoot("/")
+
+	origAdjtimex := adjtimex
+	defer func() { adjtimex = origAdjtimex }()
+
+	tests := []struct {
+		name           string
+		state          int
+		status         int32
+		expectedSync   map[string]string
+		expectedLabels source.FeatureLabels
+	}{
+		{
+			name:  "synchronized",
+			state: unix.TIME_OK,
+			expectedSync: map[string]string{
+				"synchronized": "true",
+			},
+			expectedLabels: source.FeatureLabels{
+				"ptp.capable":  true,
+				"synchronized": true,
+			},
+		},
+		{
+			name:   "unsynchronized",
+			state:  unix.TIME_ERROR,
+			status: unix.STA_UNSYNC,
+			expectedSync: map[string]string{
+				"synchronized": "false",
+			},
+			expectedLabels: source.FeatureLabels{
+				"ptp.capable": true,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			adjtimex = func(tx *unix.Timex) (int, error) {
+				tx.Status = tc.status
+				return tc.state, nil
+			}
+
+			s := timeSource{}
+			assert.Nil(t, s.Discover())
+
+			expected := &nfdv1alpha1.Features{
+				Flags: map[string]nfdv1alpha1.FlagFeatureSet{},
+				Attributes: map[string]nfdv1alpha1.AttributeFeatureSet{
+					ClocksourceFeature: nfdv1alpha1.NewAttributeFeatures(map[string]string{
+						"current":   "tsc",
+						"available": "acpi_pm,hpet,tsc",
+					}),
+					SyncFeature: nfdv1alpha1.NewAttributeFeatures(tc.expectedSync),
+				},
+				Instances: map[string]nfdv1alpha1.InstanceFeatureSet{
+					PtpFeature: nfdv1alpha1.NewInstanceFeatures([]nfdv1alpha1.InstanceFeature{
+						*nfdv1alpha1.NewInstanceFeature(map[string]string{
+							"name":                "ptp0",
+							"clock_name":          "ice-0000:18:00.0-clk",
+							"interface":           "ens1f0",
+							"pins":                "7",
+							"external_timestamps": "3",
+							"periodic_outputs":    "4",
+							"pps":                 "1",
+							"max_adjustment":      "100000000",
+						}),
+						*nfdv1alpha1.NewInstanceFeature(map[string]string{
+							"name":                "ptp1",
+							"clock_name":          "KVM virtual PTP",
+							"pins":                "0",
+							"external_timestamps": "0",
+							"periodic_outputs":    "0",
+							"pps":                 "0",
+							"max_adjustment":      "0",
+						}),
+					}),
+				},
+			}
+			assert.Equal(t, expected, s.GetFeatures())
+
+			l, err := s.GetLabels()
+			assert.Nil(t, err, err)
+			assert.Equal(t, tc.expectedLabels, l)
+		})
+	}
+}
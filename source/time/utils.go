@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package time
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	nfdv1alpha1 "sigs.k8s.io/node-feature-discovery/pkg/apis/nfd/v1alpha1"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils"
+	"sigs.k8s.io/node-feature-discovery/pkg/utils/hostpath"
+)
+
+// ptpAttrs maps the files under /sys/class/ptp/<clock> that we're reading to
+// the advertised attribute names
+var ptpAttrs = map[string]string{
+	"clock_name":            "clock_name",
+	"n_programmable_pins":   "pins",
+	"n_external_timestamps": "external_timestamps",
+	"n_periodic_outputs":    "periodic_outputs",
+	"pps_available":         "pps",
+	"max_adjustment":        "max_adjustment",
+}
+
+// adjtimex is used for querying the kernel clock state, replaceable in tests.
+var adjtimex = unix.Adjtimex
+
+// detectPtp detects the PTP hardware clocks of the system.
+func detectPtp() ([]nfdv1alpha1.InstanceFeature, error) {
+	basePath := hostpath.SysfsDir.Path("class/ptp")
+
+	clocks, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := make([]nfdv1alpha1.InstanceFeature, 0, len(clocks))
+	for _, clock := range clocks {
+		clockPath := filepath.Join(basePath, clock.Name())
+		attrs := map[string]string{"name": clock.Name()}
+		for file, attr := range ptpAttrs {
+			if v, err := utils.ReadSysfsAttr(clockPath, file); err == nil {
+				attrs[attr] = v
+			}
+		}
+		// Clocks of NICs have the network interface(s) under the parent
+		// device
+		if netdevs, err := os.ReadDir(filepath.Join(clockPath, "device/net")); err == nil && len(netdevs) > 0 {
+			names := make([]string, 0, len(netdevs))
+			for _, n := range netdevs {
+				names = append(names, n.Name())
+			}
+			attrs["interface"] = strings.Join(names, ",")
+		}
+		info = append(info, *nfdv1alpha1.NewInstanceFeature(attrs))
+	}
+	return info, nil
+}
+
+// detectClocksource returns the current and available clock sources of the
+// kernel.
+func detectClocksource() (map[string]string, error) {
+	basePath := hostpath.SysfsDir.Path("devices/system/clocksource/clocksource0")
+
+	current, err := utils.ReadSysfsAttr(basePath, "current_clocksource")
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string]string{"current": current}
+	if available, err := utils.ReadSysfsAttr(basePath, "available_clocksource"); err == nil {
+		sources := strings.Fields(available)
+		sort.Strings(sources)
+		attrs["available"] = strings.Join(sources, ",")
+	}
+	return attrs, nil
+}
+
+// detectSync returns the synchronization status of the system clock, as
+// maintained by NTP or PTP daemons (e.g. chronyd or phc2sys) through the
+// adjtimex system call.
+func detectSync() (map[string]string, error) {
+	var tx unix.Timex
+	state, err := adjtimex(&tx)
+	if err != nil {
+		return nil, fmt.Errorf("adjtimex failed: %w", err)
+	}
+	synced := state != unix.TIME_ERROR && tx.Status&unix.STA_UNSYNC == 0
+	// The error estimates are not advertised as they change constantly,
+	// which would cause an update of the NodeFeature object on every
+	// discovery round
+	return map[string]string{"synchronized": strconv.FormatBool(synced)}, nil
+}
@@ -320,6 +320,11 @@ func nfdWorkerSpec(opts ...SpecOption) *corev1.PodSpec {
 						MountPath: "/host-usr/lib",
 						ReadOnly:  true,
 					},
+					{
+						Name:      "host-proc",
+						MountPath: "/host-proc",
+						ReadOnly:  true,
+					},
 				},
 			},
 		},
@@ -362,6 +367,15 @@ func nfdWorkerSpec(opts ...SpecOption) *corev1.PodSpec {
 					},
 				},
 			},
+			{
+				Name: "host-proc",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{
+						Path: "/proc",
+						Type: newHostPathType(corev1.HostPathDirectory),
+					},
+				},
+			},
 		},
 	}
 